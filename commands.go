@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gigikoneti/tracemind/internal/eval"
+	"github.com/gigikoneti/tracemind/internal/scenario"
+	"github.com/gigikoneti/tracemind/internal/tracegen"
+)
+
+// commands are run with "tracemind <command> [flags]" instead of the server.
+var commands = map[string]func(args []string) error{
+	"generate":  runGenerate,
+	"replay":    runReplay,
+	"scenarios": runScenarios,
+}
+
+// runGenerate writes traces generated from a topology as a dataset.
+func runGenerate(args []string) error {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	topologyPath := fs.String("topology", "", "topology file (YAML or JSON)")
+	n := fs.Int("n", 10, "number of traces")
+	seed := fs.Int64("seed", 1, "random seed; the same seed generates the same traces")
+	start := fs.String("start", "", "start of the first trace, RFC 3339 (default now)")
+	interval := fs.Duration("interval", time.Second, "time between trace starts")
+	out := fs.String("out", "", "output file: .jsonl for one case per line, otherwise a JSON dataset (default stdout)")
+	fs.Parse(args)
+
+	first := time.Now()
+	if *start != "" {
+		t, err := time.Parse(time.RFC3339, *start)
+		if err != nil {
+			return fmt.Errorf("invalid -start: %w", err)
+		}
+		first = t
+	}
+
+	gen, err := loadGenerator(*topologyPath, *seed)
+	if err != nil {
+		return err
+	}
+	dataset := gen.Generate(*n, first, *interval)
+
+	if *out == "" {
+		return writeDataset(os.Stdout, dataset, false)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := writeDataset(f, dataset, strings.EqualFold(filepath.Ext(*out), ".jsonl")); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// runReplay sends generated or saved traces to a running server.
+func runReplay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	topologyPath := fs.String("topology", "", "topology file to generate traces from")
+	datasetPath := fs.String("dataset", "", "dataset file to replay instead of generating")
+	n := fs.Int("n", 10, "number of traces to generate")
+	seed := fs.Int64("seed", 1, "random seed")
+	url := fs.String("url", "http://localhost:8080/api/analyze", "endpoint each trace is POSTed to")
+	rate := fs.Float64("rate", 1, "traces sent per second; 0 sends them as fast as concurrency allows")
+	concurrency := fs.Int("concurrency", 1, "requests in flight")
+	timeout := fs.Duration("timeout", 5*time.Minute, "timeout of each request")
+	fs.Parse(args)
+
+	var cases []eval.Case
+	switch {
+	case *datasetPath != "" && *topologyPath != "":
+		return errors.New("use either -topology or -dataset")
+	case *datasetPath != "":
+		dataset, err := eval.Load(*datasetPath)
+		if err != nil {
+			return err
+		}
+		cases = dataset.Cases
+	default:
+		gen, err := loadGenerator(*topologyPath, *seed)
+		if err != nil {
+			return err
+		}
+		cases = gen.Generate(*n, time.Now(), time.Second).Cases
+	}
+
+	var interval time.Duration
+	if *rate > 0 {
+		interval = time.Duration(float64(time.Second) / *rate)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	failed := 0
+	err := tracegen.Replay(ctx, &http.Client{Timeout: *timeout}, *url, cases, interval, *concurrency, func(r tracegen.ReplayResult) {
+		if r.Err != nil {
+			failed++
+			fmt.Printf("%s trace %s: %v\n", r.CaseID, r.TraceID, r.Err)
+			return
+		}
+		fmt.Printf("%s trace %s: %d in %s\n", r.CaseID, r.TraceID, r.Status, r.Latency.Round(time.Millisecond))
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Replayed %d traces, %d failed\n", len(cases), failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d traces failed", failed, len(cases))
+	}
+	return nil
+}
+
+// runScenarios runs the failure scenario catalog through the analyzer and
+// fails unless every scenario is detected.
+func runScenarios(args []string) error {
+	fs := flag.NewFlagSet("scenarios", flag.ExitOnError)
+	run := fs.String("run", "", "only run scenarios whose name matches this regular expression")
+	n := fs.Int("n", 5, "traces generated per scenario")
+	seed := fs.Int64("seed", 1, "random seed")
+	asJSON := fs.Bool("json", false, "print results as JSON")
+	fs.Parse(args)
+
+	filter, err := regexp.Compile(*run)
+	if err != nil {
+		return fmt.Errorf("invalid -run: %w", err)
+	}
+	var scenarios []scenario.Scenario
+	for _, s := range scenario.Catalog() {
+		if filter.MatchString(s.Name) {
+			scenarios = append(scenarios, s)
+		}
+	}
+	if len(scenarios) == 0 {
+		return fmt.Errorf("no scenario matches %q", *run)
+	}
+
+	results, err := scenario.Run(context.Background(), scenarios, *n, *seed)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			return err
+		}
+	} else {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "SCENARIO\tRESULT\tFACTS\tMISSING\tUNEXPECTED\tMISATTRIBUTED\tKNOWN GAPS")
+		for _, r := range results {
+			status := "detected"
+			if !r.Detected {
+				status = fmt.Sprintf("MISSED (%d/%d traces)", len(r.FailedCases), r.Traces)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.Scenario, status, listOrDash(r.Facts), listOrDash(r.Missing), listOrDash(r.Unexpected), listOrDash(r.Misattributed), listOrDash(r.KnownGaps))
+		}
+		tw.Flush()
+	}
+
+	missed := 0
+	for _, r := range results {
+		if !r.Detected {
+			missed++
+		}
+	}
+	if missed > 0 {
+		return fmt.Errorf("%d of %d scenarios not detected", missed, len(results))
+	}
+	return nil
+}
+
+func listOrDash(items []string) string {
+	if len(items) == 0 {
+		return "-"
+	}
+	return strings.Join(items, ",")
+}
+
+func loadGenerator(topologyPath string, seed int64) (*tracegen.Generator, error) {
+	if topologyPath == "" {
+		return nil, errors.New("-topology is required")
+	}
+	topology, err := tracegen.LoadTopology(topologyPath)
+	if err != nil {
+		return nil, err
+	}
+	return tracegen.NewGenerator(topology, seed)
+}
+
+// writeDataset writes a dataset in a format eval.Load reads back: indented
+// JSON, or one case per line.
+func writeDataset(w io.Writer, dataset eval.Dataset, lines bool) error {
+	enc := json.NewEncoder(w)
+	if !lines {
+		enc.SetIndent("", "  ")
+		return enc.Encode(dataset)
+	}
+	for _, c := range dataset.Cases {
+		if err := enc.Encode(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
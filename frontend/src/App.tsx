@@ -26,9 +26,29 @@ interface SystemHealth {
   last_update: string
 }
 
+interface Evaluation {
+  score: number
+  rationale: string
+  root_cause_found: boolean
+  criteria: { completeness: number, actionability: number, hallucination: number }
+  score_stddev: number
+}
+
+const formatEvaluation = (e: Evaluation) =>
+  `Score: ${e.score.toFixed(1)}/10 · Root Cause Found: ${e.root_cause_found ? 'Yes' : 'No'}\n` +
+  `Completeness ${e.criteria.completeness.toFixed(1)} · Actionability ${e.criteria.actionability.toFixed(1)} · Hallucination ${e.criteria.hallucination.toFixed(1)}\n` +
+  e.rationale
+
+interface Claim {
+  kind: string
+  text: string
+  supported: boolean
+}
+
 interface AnalysisState {
   explanation: string
   evaluation: string
+  unsupported: Claim[]
   loading: boolean
 }
 
@@ -68,8 +88,8 @@ function App() {
   const [jsonInput, setJsonInput] = useState(JSON.stringify(DEFAULT_TRACE, null, 2))
   const [researchMode, setResearchMode] = useState(true)
 
-  const [structured, setStructured] = useState<AnalysisState>({ explanation: "", evaluation: "", loading: false })
-  const [raw, setRaw] = useState<AnalysisState>({ explanation: "", evaluation: "", loading: false })
+  const [structured, setStructured] = useState<AnalysisState>({ explanation: "", evaluation: "", unsupported: [], loading: false })
+  const [raw, setRaw] = useState<AnalysisState>({ explanation: "", evaluation: "", unsupported: [], loading: false })
 
   const [facts, setFacts] = useState<SymbolicFact[]>([])
   const [health, setHealth] = useState<SystemHealth | null>(null)
@@ -83,7 +103,7 @@ function App() {
     if (isStructured) abortStructured.current = controller
     else abortRaw.current = controller
 
-    stateSetter(prev => ({ ...prev, loading: true, explanation: "", evaluation: "" }))
+    stateSetter(prev => ({ ...prev, loading: true, explanation: "", evaluation: "", unsupported: [] }))
 
     try {
       const response = await fetch(`http://localhost:8080/api/analyze?structured=${isStructured}`, {
@@ -105,15 +125,23 @@ function App() {
         const lines = buffer.split('\n\n')
         buffer = lines.pop() || ""
 
-        for (const line of lines) {
-          if (line.startsWith('event: metadata')) {
-            const data = JSON.parse(line.split('data: ')[1])
+        for (const block of lines) {
+          // Multi-line data arrives as one data field per line.
+          const event = block.split('\n').find(l => l.startsWith('event: '))?.slice(7)
+          const payload = block.split('\n')
+            .filter(l => l.startsWith('data:'))
+            .map(l => l.slice(l.startsWith('data: ') ? 6 : 5))
+            .join('\n')
+          if (event === 'metadata') {
+            const data = JSON.parse(payload)
             setFacts(data.facts)
             setHealth(data.health)
             setCurrentTrace(data.trace)
-          } else if (line.startsWith('event: token')) {
-            const token = line.split('data: ')[1]
-            stateSetter(prev => ({ ...prev, explanation: prev.explanation + token }))
+          } else if (event === 'token') {
+            stateSetter(prev => ({ ...prev, explanation: prev.explanation + payload }))
+          } else if (event === 'verification') {
+            const data = JSON.parse(payload)
+            stateSetter(prev => ({ ...prev, unsupported: data.claims.filter((c: Claim) => !c.supported) }))
           }
         }
       }
@@ -145,8 +173,9 @@ function App() {
           explanation: state.explanation
         })
       })
+      if (!response.ok) throw new Error(await response.text())
       const data = await response.json()
-      setter(prev => ({ ...prev, evaluation: data.evaluation }))
+      setter(prev => ({ ...prev, evaluation: formatEvaluation(data.evaluation) }))
     } catch (err) {
       console.error(err)
       setter(prev => ({ ...prev, evaluation: "Evaluation failed." }))
@@ -218,6 +247,13 @@ function App() {
               {structured.explanation || (structured.loading ? "Streaming context-aware analysis..." : "Run analysis to see results.")}
             </div>
 
+            {structured.unsupported.length > 0 && (
+              <div className="verification-warning">
+                <strong>Not found in the trace:</strong>{' '}
+                {structured.unsupported.map(c => `${c.text} (${c.kind.replace('_', ' ')})`).join(', ')}
+              </div>
+            )}
+
             {structured.explanation && !structured.loading && (
               <>
                 <button className="evaluate-btn" onClick={() => handleEvaluate(true)}>Evaluate with LLM-as-a-Judge</button>
@@ -256,6 +292,13 @@ function App() {
                 {raw.explanation || (raw.loading ? "Streaming basic analysis..." : "Run analysis to compare.")}
               </div>
 
+              {raw.unsupported.length > 0 && (
+                <div className="verification-warning">
+                  <strong>Not found in the trace:</strong>{' '}
+                  {raw.unsupported.map(c => `${c.text} (${c.kind.replace('_', ' ')})`).join(', ')}
+                </div>
+              )}
+
               {raw.explanation && !raw.loading && (
                 <>
                   <button className="evaluate-btn secondary" onClick={() => handleEvaluate(false)}>Evaluate Baseline</button>
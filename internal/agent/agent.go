@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gigikoneti/tracemind/internal/llm"
+	"github.com/gigikoneti/tracemind/internal/memory"
+	"github.com/gigikoneti/tracemind/internal/models"
+	"github.com/gigikoneti/tracemind/internal/prompt"
+)
+
+const investigatorSystemPrompt = `You are an expert SRE Agent investigating an OTel trace with tools. You are given only an overview of the trace; use the tools to inspect spans, facts, other recent traces and service baselines before concluding.
+
+Investigate efficiently: start from the facts and the failing or slowest spans, and stop once the root cause is clear.
+
+Your final answer must:
+1. Explain the root cause and how it propagated, citing span IDs.
+2. Say whether this is an isolated incident or part of a systemic trend.
+3. Provide high-priority remediation steps.`
+
+// PromptInvestigator names the agent prompt template, rendered with an
+// InvestigatorPromptData.
+const PromptInvestigator = "investigator"
+
+const investigatorTemplate = `{{.Overview}}`
+
+func init() {
+	prompt.Register(PromptInvestigator, investigatorSystemPrompt, investigatorTemplate, InvestigatorPromptData{})
+}
+
+// InvestigatorPromptData is what the agent template is rendered with: the
+// analysis and a pre-rendered overview of the trace.
+type InvestigatorPromptData struct {
+	models.TraceAnalysis
+	Overview string
+}
+
+// Investigate lets the engine explore an analyzed trace through tool calls,
+// reporting each call and result through onEvent, and returns the final
+// explanation.
+func Investigate(ctx context.Context, engine *llm.Engine, analysis models.TraceAnalysis, store *memory.Store, maxSteps int, onEvent func(llm.AgentEvent)) (*llm.GenerateResponse, error) {
+	toolbox := &Toolbox{Analysis: analysis, Memory: store}
+
+	system, user, ref, err := prompt.Render(PromptInvestigator, InvestigatorPromptData{TraceAnalysis: analysis, Overview: overview(analysis)})
+	if err != nil {
+		return nil, err
+	}
+	req := llm.PromptRequest(user)
+	req.System = system
+
+	resp, err := engine.RunAgent(ctx, req, toolbox.Tools(), toolbox.Execute, maxSteps, onEvent)
+	if err != nil {
+		return nil, err
+	}
+	resp.Prompt = &ref
+	return resp, nil
+}
+
+// overview describes the shape of a trace without listing its spans.
+func overview(analysis models.TraceAnalysis) string {
+	trace := analysis.Trace
+	start, end := trace.TimeWindow()
+
+	ids := make(map[string]bool, len(trace.Spans))
+	for _, s := range trace.Spans {
+		ids[s.SpanID] = true
+	}
+
+	counts := make(map[string]int)
+	errors := 0
+	var roots []string
+	for i := range trace.Spans {
+		s := &trace.Spans[i]
+		counts[s.Name]++
+		if s.Status.Code == "ERROR" {
+			errors++
+		}
+		if s.ParentSpanID == "" || !ids[s.ParentSpanID] {
+			roots = append(roots, fmt.Sprintf("%s (%s, %s, %.2fms)", s.SpanID, s.Name, s.Status.Code, s.LatencyMs()))
+		}
+	}
+
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	services := make([]string, 0, len(names))
+	for _, name := range names {
+		services = append(services, fmt.Sprintf("%s ×%d", name, counts[name]))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Investigate trace %s.\n\n", trace.TraceID))
+	sb.WriteString(fmt.Sprintf("- Spans: %d (%d with status ERROR)\n", len(trace.Spans), errors))
+	sb.WriteString(fmt.Sprintf("- Duration: %.2fms\n", float64(end.Sub(start).Microseconds())/1000.0))
+	sb.WriteString(fmt.Sprintf("- Root spans: %s\n", strings.Join(roots, "; ")))
+	sb.WriteString(fmt.Sprintf("- Span names: %s\n", strings.Join(services, ", ")))
+	sb.WriteString(fmt.Sprintf("- Symbolic facts available: %d (call get_facts)\n", len(analysis.SymbolicFacts)))
+	return sb.String()
+}
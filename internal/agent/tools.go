@@ -0,0 +1,222 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gigikoneti/tracemind/internal/llm"
+	"github.com/gigikoneti/tracemind/internal/memory"
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+// maxListed caps list results so a single tool call cannot flood the context.
+const maxListed = 50
+
+// Toolbox exposes one analyzed trace and symbolic memory to an agent.
+type Toolbox struct {
+	Analysis models.TraceAnalysis
+	Memory   *memory.Store
+}
+
+func objectSchema(properties map[string]any, required ...string) map[string]any {
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// Tools describes the functions the agent may call.
+func (t *Toolbox) Tools() []llm.Tool {
+	spanID := map[string]any{"span_id": map[string]any{"type": "string", "description": "ID of a span in the trace under investigation."}}
+
+	return []llm.Tool{
+		{
+			Name:        "get_facts",
+			Description: "Returns the symbolic facts computed for the trace, with correlated metric anomalies, recent changes and matching runbook sections.",
+			Parameters:  objectSchema(map[string]any{}),
+		},
+		{
+			Name:        "get_span",
+			Description: "Returns one span of the trace with its attributes, status, latency and number of children.",
+			Parameters:  objectSchema(spanID, "span_id"),
+		},
+		{
+			Name:        "get_children",
+			Description: "Lists the direct children of a span.",
+			Parameters:  objectSchema(spanID, "span_id"),
+		},
+		{
+			Name:        "search_traces",
+			Description: "Searches recent traces in memory, returning summaries of those with a matching span.",
+			Parameters: objectSchema(map[string]any{
+				"service":        map[string]any{"type": "string", "description": "Span or service name to match."},
+				"status":         map[string]any{"type": "string", "description": "Span status code to match, e.g. ERROR."},
+				"min_latency_ms": map[string]any{"type": "number", "description": "Minimum span latency."},
+				"limit":          map[string]any{"type": "integer", "description": "Maximum traces to return (default 10)."},
+			}),
+		},
+		{
+			Name:        "get_service_baseline",
+			Description: "Returns the recent span count, error count and latency of a service across all traces in memory.",
+			Parameters:  objectSchema(map[string]any{"service": map[string]any{"type": "string"}}, "service"),
+		},
+	}
+}
+
+// Execute runs a tool call against the trace and memory.
+func (t *Toolbox) Execute(ctx context.Context, call llm.ToolCall) (string, error) {
+	var args struct {
+		SpanID       string  `json:"span_id"`
+		Service      string  `json:"service"`
+		Status       string  `json:"status"`
+		MinLatencyMs float64 `json:"min_latency_ms"`
+		Limit        int     `json:"limit"`
+	}
+	if len(call.Arguments) > 0 {
+		if err := json.Unmarshal(call.Arguments, &args); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+
+	var result interface{}
+	switch call.Name {
+	case "get_facts":
+		result = map[string]interface{}{
+			"facts":            t.Analysis.SymbolicFacts,
+			"metric_anomalies": t.Analysis.MetricAnomalies,
+			"recent_changes":   t.Analysis.RecentChanges,
+			"runbooks":         t.Analysis.Runbooks,
+		}
+	case "get_span":
+		span, ok := t.span(args.SpanID)
+		if !ok {
+			return "", fmt.Errorf("span %q not found", args.SpanID)
+		}
+		result = map[string]interface{}{
+			"span":       span,
+			"latency_ms": span.LatencyMs(),
+			"children":   len(t.children(args.SpanID)),
+		}
+	case "get_children":
+		if _, ok := t.span(args.SpanID); !ok {
+			return "", fmt.Errorf("span %q not found", args.SpanID)
+		}
+		result = t.listChildren(args.SpanID)
+	case "search_traces":
+		result = t.searchTraces(args.Service, args.Status, args.MinLatencyMs, args.Limit)
+	case "get_service_baseline":
+		stats, ok := t.Memory.GetServiceStats()[args.Service]
+		if !ok {
+			return "", fmt.Errorf("no recent spans for service %q", args.Service)
+		}
+		result = map[string]interface{}{"service": args.Service, "stats": stats}
+	default:
+		return "", fmt.Errorf("unknown tool %q", call.Name)
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+type spanSummary struct {
+	SpanID    string  `json:"span_id"`
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	Message   string  `json:"message,omitempty"`
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+func summarize(s *models.Span) spanSummary {
+	return spanSummary{
+		SpanID:    s.SpanID,
+		Name:      s.Name,
+		Status:    s.Status.Code,
+		Message:   s.Status.Message,
+		LatencyMs: s.LatencyMs(),
+	}
+}
+
+func (t *Toolbox) span(id string) (*models.Span, bool) {
+	for i := range t.Analysis.Trace.Spans {
+		if t.Analysis.Trace.Spans[i].SpanID == id {
+			return &t.Analysis.Trace.Spans[i], true
+		}
+	}
+	return nil, false
+}
+
+func (t *Toolbox) children(id string) []*models.Span {
+	var children []*models.Span
+	for i := range t.Analysis.Trace.Spans {
+		if t.Analysis.Trace.Spans[i].ParentSpanID == id {
+			children = append(children, &t.Analysis.Trace.Spans[i])
+		}
+	}
+	return children
+}
+
+func (t *Toolbox) listChildren(id string) map[string]interface{} {
+	children := t.children(id)
+	listed := make([]spanSummary, 0, len(children))
+	for i, c := range children {
+		if i == maxListed {
+			break
+		}
+		listed = append(listed, summarize(c))
+	}
+	return map[string]interface{}{
+		"total":    len(children),
+		"children": listed,
+	}
+}
+
+func (t *Toolbox) searchTraces(service, status string, minLatencyMs float64, limit int) []map[string]interface{} {
+	if limit <= 0 || limit > maxListed {
+		limit = 10
+	}
+
+	var results []map[string]interface{}
+	traces := t.Memory.RecentTraces()
+	for i := len(traces) - 1; i >= 0 && len(results) < limit; i-- {
+		var matches []spanSummary
+		errors := 0
+		for j := range traces[i].Spans {
+			s := &traces[i].Spans[j]
+			if s.Status.Code == "ERROR" {
+				errors++
+			}
+			if service != "" && s.Name != service {
+				continue
+			}
+			if status != "" && !strings.EqualFold(s.Status.Code, status) {
+				continue
+			}
+			if s.LatencyMs() < minLatencyMs {
+				continue
+			}
+			if len(matches) < 5 {
+				matches = append(matches, summarize(s))
+			}
+		}
+		if len(matches) == 0 {
+			continue
+		}
+
+		start, end := traces[i].TimeWindow()
+		results = append(results, map[string]interface{}{
+			"trace_id":       traces[i].TraceID,
+			"spans":          len(traces[i].Spans),
+			"errors":         errors,
+			"start":          start,
+			"duration_ms":    float64(end.Sub(start).Microseconds()) / 1000.0,
+			"matching_spans": matches,
+		})
+	}
+	return results
+}
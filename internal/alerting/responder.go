@@ -0,0 +1,229 @@
+package alerting
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gigikoneti/tracemind/internal/analyzer"
+	"github.com/gigikoneti/tracemind/internal/llm"
+	"github.com/gigikoneti/tracemind/internal/memory"
+	"github.com/gigikoneti/tracemind/internal/models"
+	"github.com/gigikoneti/tracemind/internal/notify"
+)
+
+// defaultRetryInterval is used when Responder.RetryInterval is unset.
+const defaultRetryInterval = time.Minute
+
+// serviceLabels are checked in order to find the service an alert is about.
+var serviceLabels = []string{"service", "service_name", "app", "app_kubernetes_io_name", "job", "container"}
+
+// Responder turns Alertmanager alerts into trace explanations.
+type Responder struct {
+	Memory   *memory.Store
+	Pipeline *analyzer.Pipeline
+	Engine   *llm.Engine
+	Store    *Store
+	// Notifier is optional; when set, explanations are posted back through it.
+	Notifier *notify.Notifier
+	// Lookback widens the alert window backwards, since the traces that
+	// caused an alert usually precede its startsAt.
+	Lookback       time.Duration
+	ExplainTimeout time.Duration
+	// RetryInterval is how long a failed analysis is kept before a
+	// re-delivery of its still firing alert analyzes it again.
+	RetryInterval time.Duration
+	Usage         *llm.UsageTracker
+}
+
+// Handle records every alert in the payload and analyzes newly firing ones
+// in the background, as well as firing ones whose analysis failed at
+// least RetryInterval ago.
+func (r *Responder) Handle(payload models.AlertmanagerWebhook) {
+	for _, alert := range payload.Alerts {
+		for k, v := range payload.CommonLabels {
+			if _, ok := alert.Labels[k]; !ok {
+				if alert.Labels == nil {
+					alert.Labels = make(map[string]string)
+				}
+				alert.Labels[k] = v
+			}
+		}
+		if alert.Fingerprint == "" {
+			alert.Fingerprint = fingerprint(alert.Labels)
+		}
+
+		existing, seen := r.Store.Get(alert.Fingerprint)
+		if alert.Status == "resolved" {
+			if seen {
+				existing.Status = "resolved"
+				r.Store.Put(existing)
+			}
+			continue
+		}
+		if seen && existing.Status == "firing" && !r.retry(existing) {
+			continue
+		}
+
+		analysis := r.newAnalysis(alert)
+		r.Store.Put(analysis)
+		go r.analyze(analysis)
+	}
+}
+
+// retry reports whether a firing alert's analysis failed long enough ago
+// to try again, e.g. because its traces had not arrived yet.
+func (r *Responder) retry(a models.AlertAnalysis) bool {
+	if a.Error == "" || a.AnalyzedAt == nil {
+		return false
+	}
+	interval := r.RetryInterval
+	if interval <= 0 {
+		interval = defaultRetryInterval
+	}
+	return time.Since(*a.AnalyzedAt) >= interval
+}
+
+func (r *Responder) newAnalysis(alert models.Alert) models.AlertAnalysis {
+	end := alert.EndsAt
+	now := time.Now()
+	if end.IsZero() || end.Year() <= 1 || end.After(now) {
+		end = now
+	}
+	start := alert.StartsAt
+	if start.IsZero() {
+		start = end
+	}
+
+	return models.AlertAnalysis{
+		Fingerprint: alert.Fingerprint,
+		AlertName:   alert.Labels["alertname"],
+		Status:      "firing",
+		Service:     serviceFromLabels(alert.Labels),
+		Labels:      alert.Labels,
+		WindowStart: start.Add(-r.Lookback),
+		WindowEnd:   end,
+		ReceivedAt:  now,
+	}
+}
+
+func (r *Responder) analyze(a models.AlertAnalysis) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.ExplainTimeout)
+	defer cancel()
+
+	defer func() {
+		analyzedAt := time.Now()
+		a.AnalyzedAt = &analyzedAt
+		if current, ok := r.Store.Get(a.Fingerprint); ok && current.Status == "resolved" {
+			a.Status = "resolved"
+		}
+		r.Store.Put(a)
+		r.postBack(a)
+	}()
+
+	traces := r.Memory.FindTraces(a.Service, a.WindowStart, a.WindowEnd)
+	if len(traces) == 0 {
+		a.Error = fmt.Sprintf("no traces found for service %q between %s and %s", a.Service, a.WindowStart.Format(time.RFC3339), a.WindowEnd.Format(time.RFC3339))
+		return
+	}
+	for _, t := range traces {
+		a.TraceIDs = append(a.TraceIDs, t.TraceID)
+	}
+
+	analysis := r.Pipeline.Analyze(ctx, worstTrace(traces))
+	a.Facts = analysis.SymbolicFacts
+
+	if r.Engine == nil {
+		return
+	}
+
+	resp, err := r.Engine.ExplainTraceStream(ctx, analysis, true, func(string) {})
+	if err != nil {
+		a.Error = fmt.Sprintf("explanation failed: %v", err)
+		log.Printf("Alert %s: %s", a.Fingerprint, a.Error)
+		return
+	}
+	r.Usage.Record(r.Engine.ConnectionID(), "alert", resp.Usage)
+	a.Explanation = strings.TrimSpace(resp.Content)
+	a.Prompt = resp.Prompt
+}
+
+func (r *Responder) postBack(a models.AlertAnalysis) {
+	if r.Notifier == nil || a.Explanation == "" {
+		return
+	}
+
+	severity := a.Labels["severity"]
+	if severity == "" {
+		severity = "warning"
+	}
+
+	r.Notifier.NotifyAsync(notify.Notification{
+		Title:    fmt.Sprintf("TraceMind analysis of %s", a.AlertName),
+		Message:  a.Explanation,
+		Severity: severity,
+		Source:   "alertmanager",
+		Service:  a.Service,
+		DedupKey: "alert/" + a.Fingerprint,
+		Details: map[string]interface{}{
+			"fingerprint": a.Fingerprint,
+			"trace_ids":   a.TraceIDs,
+		},
+	})
+}
+
+// worstTrace prefers the trace with the most errors, then the slowest span.
+func worstTrace(traces []models.Trace) models.Trace {
+	best := traces[0]
+	bestErrors, bestLatency := traceSeverity(best)
+	for _, t := range traces[1:] {
+		errors, latency := traceSeverity(t)
+		if errors > bestErrors || (errors == bestErrors && latency > bestLatency) {
+			best, bestErrors, bestLatency = t, errors, latency
+		}
+	}
+	return best
+}
+
+func traceSeverity(t models.Trace) (int, float64) {
+	var errors int
+	var maxLatency float64
+	for _, span := range t.Spans {
+		if span.Status.Code == "ERROR" {
+			errors++
+		}
+		if l := span.LatencyMs(); l > maxLatency {
+			maxLatency = l
+		}
+	}
+	return errors, maxLatency
+}
+
+func serviceFromLabels(labels map[string]string) string {
+	for _, key := range serviceLabels {
+		if v := labels[key]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// fingerprint derives a stable key for alerts sent without one.
+func fingerprint(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		h.Write([]byte(k + "=" + labels[k] + "\n"))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
@@ -0,0 +1,48 @@
+package alerting
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+// Store keeps alert analyses keyed by Alertmanager fingerprint.
+type Store struct {
+	mu       sync.RWMutex
+	analyses map[string]models.AlertAnalysis
+}
+
+func NewStore() *Store {
+	return &Store{
+		analyses: make(map[string]models.AlertAnalysis),
+	}
+}
+
+func (s *Store) Put(a models.AlertAnalysis) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.analyses[a.Fingerprint] = a
+}
+
+func (s *Store) Get(fingerprint string) (models.AlertAnalysis, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	a, ok := s.analyses[fingerprint]
+	return a, ok
+}
+
+// List returns analyses with the most recently received first.
+func (s *Store) List() []models.AlertAnalysis {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]models.AlertAnalysis, 0, len(s.analyses))
+	for _, a := range s.analyses {
+		list = append(list, a)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ReceivedAt.After(list[j].ReceivedAt)
+	})
+	return list
+}
@@ -0,0 +1,62 @@
+package analyzer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+// CorrelateChanges returns RECENT_CHANGE facts for changes to services
+// implicated by facts that landed within window before the trace (or during
+// it), together with the matching change events.
+func CorrelateChanges(trace models.Trace, facts []models.SymbolicFact, changes []models.ChangeEvent, window time.Duration) ([]models.SymbolicFact, []models.ChangeEvent) {
+	var changeFacts []models.SymbolicFact
+	var matched []models.ChangeEvent
+
+	if len(trace.Spans) == 0 || len(changes) == 0 {
+		return changeFacts, matched
+	}
+
+	implicated := make(map[string]bool)
+	for _, f := range facts {
+		if f.Service != "" {
+			implicated[f.Service] = true
+		}
+	}
+
+	start, end := trace.TimeWindow()
+	from := start.Add(-window)
+
+	for _, c := range changes {
+		if !implicated[c.Service] || c.Timestamp.Before(from) || c.Timestamp.After(end) {
+			continue
+		}
+		matched = append(matched, c)
+
+		what := c.Kind
+		if c.Version != "" {
+			what += " " + c.Version
+		}
+		when := fmt.Sprintf("%s before the trace", start.Sub(c.Timestamp).Round(time.Second))
+		if c.Timestamp.After(start) {
+			when = fmt.Sprintf("during the trace, %s after it started", c.Timestamp.Sub(start).Round(time.Millisecond))
+		}
+		desc := fmt.Sprintf("Service '%s' had a %s %s", c.Service, what, when)
+		if c.Author != "" {
+			desc += fmt.Sprintf(" (by %s)", c.Author)
+		}
+		if c.Description != "" {
+			desc += ": " + c.Description
+		}
+
+		changeFacts = append(changeFacts, models.SymbolicFact{
+			Type:        "RECENT_CHANGE",
+			Service:     c.Service,
+			Description: desc + ".",
+			Severity:    "warning",
+		})
+	}
+
+	return changeFacts, matched
+}
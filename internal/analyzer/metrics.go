@@ -0,0 +1,139 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+// metricWindowPadding widens the trace window to cover typical scrape intervals.
+const metricWindowPadding = time.Minute
+
+// saturationMarkers identify ratio metrics whose absolute value is meaningful
+// even without a baseline (e.g. CPU throttling or pool utilization).
+var saturationMarkers = []string{"utilization", "saturation", "throttl", "_ratio", "usage_percent"}
+
+// percentMarkers identify saturation metrics reported on a 0-100 scale
+// rather than as a 0-1 ratio.
+var percentMarkers = []string{"percent", "_pct"}
+
+// CorrelateMetrics finds metric anomalies for the services implicated by facts
+// during the trace's time window. A series is anomalous when its peak in the
+// window is at least 3 standard deviations and 25% above its earlier baseline,
+// or when a saturation-style ratio reaches 90% (percentages are scaled to
+// ratios first).
+func CorrelateMetrics(trace models.Trace, facts []models.SymbolicFact, series []models.MetricSeries) []models.MetricAnomaly {
+	var anomalies []models.MetricAnomaly
+
+	if len(trace.Spans) == 0 || len(series) == 0 {
+		return anomalies
+	}
+
+	implicated := make(map[string]bool)
+	for _, f := range facts {
+		if f.Service != "" {
+			implicated[f.Service] = true
+		}
+	}
+
+	start, end := trace.TimeWindow()
+	from, to := start.Add(-metricWindowPadding), end.Add(metricWindowPadding)
+
+	for _, s := range series {
+		if !implicated[s.Service] {
+			continue
+		}
+
+		var baseline []float64
+		var peak models.MetricPoint
+		inWindow := false
+		for _, p := range s.Points {
+			if p.Timestamp.Before(from) {
+				baseline = append(baseline, p.Value)
+				continue
+			}
+			if p.Timestamp.After(to) {
+				continue
+			}
+			if !inWindow || p.Value > peak.Value {
+				peak = p
+				inWindow = true
+			}
+		}
+		if !inWindow {
+			continue
+		}
+
+		if len(baseline) >= 3 {
+			mean, std := meanStd(baseline)
+			if std > 0 {
+				z := (peak.Value - mean) / std
+				if z >= 3 && peak.Value > mean*1.25 {
+					anomalies = append(anomalies, models.MetricAnomaly{
+						Service:      s.Service,
+						Metric:       s.Name,
+						Peak:         peak.Value,
+						BaselineMean: mean,
+						ZScore:       z,
+						At:           peak.Timestamp,
+						Description:  fmt.Sprintf("%s of '%s' peaked at %.4g (baseline %.4g, %.1fσ) during the trace.", s.Name, s.Service, peak.Value, mean, z),
+					})
+					continue
+				}
+			}
+		}
+
+		if ratio, ok := saturationRatio(s.Name, peak.Value); ok && ratio >= 0.9 && ratio <= 1 {
+			anomalies = append(anomalies, models.MetricAnomaly{
+				Service:     s.Service,
+				Metric:      s.Name,
+				Peak:        peak.Value,
+				At:          peak.Timestamp,
+				Description: fmt.Sprintf("%s of '%s' reached %.0f%% saturation during the trace.", s.Name, s.Service, ratio*100),
+			})
+		}
+	}
+
+	return anomalies
+}
+
+// saturationRatio returns the value of a saturation metric as a 0-1 ratio,
+// scaling percentages down, and false for other metrics.
+func saturationRatio(name string, value float64) (float64, bool) {
+	name = strings.ToLower(name)
+	if !containsAny(name, saturationMarkers) {
+		return 0, false
+	}
+	if containsAny(name, percentMarkers) {
+		return value / 100, true
+	}
+	return value, true
+}
+
+func containsAny(s string, markers []string) bool {
+	for _, marker := range markers {
+		if strings.Contains(s, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func meanStd(values []float64) (float64, float64) {
+	var mean float64
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(values))
+
+	return mean, math.Sqrt(variance)
+}
@@ -0,0 +1,63 @@
+package analyzer
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gigikoneti/tracemind/internal/k8s"
+	"github.com/gigikoneti/tracemind/internal/memory"
+	"github.com/gigikoneti/tracemind/internal/models"
+	"github.com/gigikoneti/tracemind/internal/runbook"
+	"github.com/gigikoneti/tracemind/internal/similar"
+)
+
+// Pipeline gathers the symbolic facts and correlated system context that
+// accompany a trace into the LLM prompt.
+type Pipeline struct {
+	Memory *memory.Store
+	// K8s is optional; when set, workload state is attached as facts.
+	K8s *k8s.Enricher
+	// ChangeWindow is how long before a trace a change still counts as recent.
+	ChangeWindow time.Duration
+	// Similar is optional; when set, past analyses of similar traces are
+	// retrieved into the prompt.
+	Similar *similar.Index
+	// SimilarTopK and SimilarMinScore bound the retrieved analyses.
+	SimilarTopK     int
+	SimilarMinScore float64
+	// Runbooks is optional; when set, the RunbookTopK best matching
+	// runbook sections are attached to ground remediation.
+	Runbooks    *runbook.Store
+	RunbookTopK int
+}
+
+func (p *Pipeline) Analyze(ctx context.Context, trace models.Trace) models.TraceAnalysis {
+	facts := AnalyzeTrace(trace)
+	if p.K8s != nil {
+		facts = append(facts, p.K8s.Enrich(ctx, trace)...)
+	}
+
+	start, _ := trace.TimeWindow()
+	changeFacts, changes := CorrelateChanges(trace, facts, p.Memory.Changes("", start.Add(-p.ChangeWindow)), p.ChangeWindow)
+
+	analysis := models.TraceAnalysis{
+		Trace:           trace,
+		SymbolicFacts:   append(facts, changeFacts...),
+		SystemContext:   p.Memory.GetHealth(),
+		MetricAnomalies: CorrelateMetrics(trace, facts, p.Memory.MetricSeries()),
+		RecentChanges:   changes,
+	}
+
+	if p.Similar != nil && p.SimilarTopK > 0 {
+		matches, err := p.Similar.Search(ctx, analysis, p.SimilarTopK, p.SimilarMinScore)
+		if err != nil {
+			log.Printf("Similar incident search failed for trace %s: %v", trace.TraceID, err)
+		}
+		analysis.SimilarIncidents = matches
+	}
+	if p.Runbooks != nil {
+		analysis.Runbooks = p.Runbooks.Retrieve(analysis, p.RunbookTopK)
+	}
+	return analysis
+}
@@ -0,0 +1,253 @@
+// Package cache stores LLM explanations by trace fingerprint so repeated
+// analyses of the same failure are replayed instead of regenerated.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gigikoneti/tracemind/internal/llm"
+)
+
+// Event is one server-sent event of an explanation stream.
+type Event struct {
+	Name string `json:"name"`
+	Data string `json:"data"`
+}
+
+// GenerateFunc produces an explanation, emitting its stream events as they
+// happen.
+type GenerateFunc func(ctx context.Context, emit func(Event)) (*llm.GenerateResponse, error)
+
+// Source tells how a request was served.
+type Source string
+
+const (
+	SourceMiss      Source = "miss"
+	SourceHit       Source = "hit"
+	SourceCoalesced Source = "coalesced"
+)
+
+type entry struct {
+	key       string
+	events    []Event
+	response  *llm.GenerateResponse
+	size      int
+	expiresAt time.Time
+}
+
+// flight is a generation in progress that any number of requests follow.
+type flight struct {
+	mu       sync.Mutex
+	events   []Event
+	update   chan struct{} // closed and replaced on every event
+	finished bool
+	response *llm.GenerateResponse
+	err      error
+
+	waiters int
+	cancel  context.CancelFunc
+}
+
+type Stats struct {
+	Entries   int   `json:"entries"`
+	Bytes     int   `json:"bytes"`
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Coalesced int64 `json:"coalesced"`
+}
+
+// Cache is an LRU of explanation streams bounded by entry count and total
+// size, with a TTL per entry.
+type Cache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	maxBytes   int
+	entries    map[string]*list.Element
+	order      *list.List // front is most recently used
+	bytes      int
+	inflight   map[string]*flight
+	stats      Stats
+}
+
+func New(ttl time.Duration, maxEntries, maxBytes int) *Cache {
+	return &Cache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		inflight:   make(map[string]*flight),
+	}
+}
+
+// Do replays the cached stream for key, joins an identical generation in
+// progress, or starts generate, emitting events as they happen in every
+// case. A started generation keeps running while any caller still follows
+// it, so one client disconnecting does not fail the others. Failed
+// generations are not cached.
+func (c *Cache) Do(ctx context.Context, key string, generate GenerateFunc, emit func(Event)) (*llm.GenerateResponse, Source, error) {
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*entry)
+		if time.Now().Before(e.expiresAt) {
+			c.order.MoveToFront(el)
+			c.stats.Hits++
+			c.mu.Unlock()
+
+			for _, ev := range e.events {
+				emit(ev)
+			}
+			resp := *e.response
+			return &resp, SourceHit, nil
+		}
+		c.remove(el)
+	}
+
+	f, ok := c.inflight[key]
+	source := SourceCoalesced
+	if ok {
+		c.stats.Coalesced++
+	} else {
+		source = SourceMiss
+		c.stats.Misses++
+		f = &flight{update: make(chan struct{})}
+		c.inflight[key] = f
+
+		genCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f.cancel = cancel
+		go c.run(genCtx, key, f, generate)
+	}
+	f.mu.Lock()
+	f.waiters++
+	f.mu.Unlock()
+	c.mu.Unlock()
+
+	resp, err := f.follow(ctx, emit)
+	if err != nil {
+		return nil, source, err
+	}
+	return resp, source, nil
+}
+
+func (c *Cache) run(ctx context.Context, key string, f *flight, generate GenerateFunc) {
+	defer f.cancel()
+
+	resp, err := generate(ctx, f.publish)
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	if err == nil {
+		c.add(key, f.events, resp)
+	}
+	c.mu.Unlock()
+
+	f.mu.Lock()
+	f.finished = true
+	f.response, f.err = resp, err
+	close(f.update)
+	f.mu.Unlock()
+}
+
+func (f *flight) publish(ev Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, ev)
+	close(f.update)
+	f.update = make(chan struct{})
+}
+
+// follow emits every event of the flight, from the first, until it finishes
+// or ctx is done. The last follower to leave cancels the generation.
+func (f *flight) follow(ctx context.Context, emit func(Event)) (*llm.GenerateResponse, error) {
+	defer func() {
+		f.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 && !f.finished {
+			f.cancel()
+		}
+		f.mu.Unlock()
+	}()
+
+	next := 0
+	for {
+		f.mu.Lock()
+		pending := f.events[next:]
+		update, finished := f.update, f.finished
+		resp, err := f.response, f.err
+		f.mu.Unlock()
+
+		for _, ev := range pending {
+			emit(ev)
+		}
+		next += len(pending)
+
+		if finished {
+			if err != nil {
+				return nil, err
+			}
+			out := *resp
+			return &out, nil
+		}
+
+		select {
+		case <-update:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (c *Cache) add(key string, events []Event, resp *llm.GenerateResponse) {
+	size := len(resp.Content)
+	for _, ev := range events {
+		size += len(ev.Name) + len(ev.Data)
+	}
+	if c.maxBytes > 0 && size > c.maxBytes {
+		return
+	}
+
+	el := c.order.PushFront(&entry{
+		key:       key,
+		events:    events,
+		response:  resp,
+		size:      size,
+		expiresAt: time.Now().Add(c.ttl),
+	})
+	c.entries[key] = el
+	c.bytes += size
+
+	for c.order.Len() > 0 && ((c.maxEntries > 0 && c.order.Len() > c.maxEntries) || (c.maxBytes > 0 && c.bytes > c.maxBytes)) {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *Cache) remove(el *list.Element) {
+	e := c.order.Remove(el).(*entry)
+	delete(c.entries, e.key)
+	c.bytes -= e.size
+}
+
+// Stats reports the cache size and how requests were served.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.order.Len()
+	stats.Bytes = c.bytes
+	return stats
+}
+
+// Purge drops every cached entry.
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+	c.bytes = 0
+}
@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+// healthBucket is the error-rate granularity at which system health is
+// considered unchanged.
+const healthBucket = 0.05
+
+// Fingerprint identifies an explanation request by what the model would be
+// shown rather than by trace identity: the shape of the span tree (names,
+// status codes and latencies in doubling buckets, with sibling order
+// ignored), the facts, metric anomalies, recent changes and runbook
+// sections as rendered into the prompt, the bucketed system health, and
+// params such as the prompt version, model and output mode. Similar
+// incidents count only by their distinct summaries: their IDs, scores and
+// explanations change with every repeat of a failure that gets indexed.
+// withIDs adds span IDs for outputs that cite them, so those are only
+// reused for the same trace.
+func Fingerprint(analysis models.TraceAnalysis, withIDs bool, params ...string) string {
+	h := sha256.New()
+
+	for _, p := range params {
+		fmt.Fprintf(h, "param:%s\n", p)
+	}
+
+	fmt.Fprintf(h, "trace:%s\n", traceShape(analysis.Trace, withIDs))
+
+	facts := make([]string, 0, len(analysis.SymbolicFacts))
+	for _, f := range analysis.SymbolicFacts {
+		facts = append(facts, fmt.Sprintf("%s|%s|%s|%q", f.Severity, f.Type, f.Service, f.Description))
+	}
+	sort.Strings(facts)
+	fmt.Fprintf(h, "facts:%s\n", strings.Join(facts, ","))
+
+	// These sections are ranked or chronological, so their order is part
+	// of the prompt.
+	for _, m := range analysis.MetricAnomalies {
+		fmt.Fprintf(h, "anomaly:%s|%s|%q\n", m.Service, m.Metric, m.Description)
+	}
+	for _, c := range analysis.RecentChanges {
+		fmt.Fprintf(h, "change:%s|%s|%s|%s|%s|%q\n", c.Timestamp.UTC().Format(time.RFC3339), c.Service, c.Kind, c.Version, c.Author, c.Description)
+	}
+	for _, rb := range analysis.Runbooks {
+		fmt.Fprintf(h, "runbook:%s|%s|%q\n", rb.File, rb.Heading, rb.Content)
+	}
+
+	summaries := make(map[string]bool)
+	for _, inc := range analysis.SimilarIncidents {
+		summaries[inc.Summary] = true
+	}
+	similar := make([]string, 0, len(summaries))
+	for s := range summaries {
+		similar = append(similar, fmt.Sprintf("%q", s))
+	}
+	sort.Strings(similar)
+	fmt.Fprintf(h, "similar:%s\n", strings.Join(similar, ","))
+
+	health := analysis.SystemContext
+	slowest := append([]string(nil), health.SlowestServices...)
+	sort.Strings(slowest)
+	fmt.Fprintf(h, "health:%d|%s\n", int(math.Round(health.RecentErrorRate/healthBucket)), strings.Join(slowest, ","))
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// traceShape renders the span tree canonically, children sorted by their
+// own rendering so concurrent siblings in a different order match.
+func traceShape(trace models.Trace, withIDs bool) string {
+	byID := make(map[string]int, len(trace.Spans))
+	for i, s := range trace.Spans {
+		byID[s.SpanID] = i
+	}
+	children := make([][]int, len(trace.Spans))
+	var roots []int
+	for i, s := range trace.Spans {
+		p, ok := byID[s.ParentSpanID]
+		if s.ParentSpanID == "" || !ok || p == i {
+			roots = append(roots, i)
+			continue
+		}
+		children[p] = append(children[p], i)
+	}
+
+	visiting := make([]bool, len(trace.Spans))
+	var render func(i int) string
+	render = func(i int) string {
+		s := &trace.Spans[i]
+		node := fmt.Sprintf("%s|%s|%d", s.Name, s.Status.Code, latencyBucket(s.LatencyMs()))
+		if withIDs {
+			node = s.SpanID + "|" + node
+		}
+		if visiting[i] {
+			return node
+		}
+		visiting[i] = true
+
+		parts := make([]string, 0, len(children[i]))
+		for _, c := range children[i] {
+			parts = append(parts, render(c))
+		}
+		sort.Strings(parts)
+		return node + "(" + strings.Join(parts, ",") + ")"
+	}
+
+	parts := make([]string, 0, len(roots))
+	for _, r := range roots {
+		parts = append(parts, render(r))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+func latencyBucket(ms float64) int {
+	if ms < 1 {
+		return 0
+	}
+	return int(math.Log2(ms)) + 1
+}
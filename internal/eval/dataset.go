@@ -0,0 +1,207 @@
+// Package eval scores explanations deterministically against labeled
+// traces, so prompts and models can be regression-tested without relying
+// on a second LLM.
+package eval
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+// Labels are the ground truth of a trace.
+type Labels struct {
+	RootCauseService string `json:"root_cause_service"`
+	// RootCauseSpanIDs are the spans where the failure originated.
+	RootCauseSpanIDs []string `json:"root_cause_span_ids,omitempty"`
+	// AffectedServices are the services a good explanation should mention;
+	// the root cause service is always included.
+	AffectedServices []string `json:"affected_services,omitempty"`
+	Notes            string   `json:"notes,omitempty"`
+}
+
+// Case is a trace and, when labeled, its ground truth.
+type Case struct {
+	ID     string       `json:"id"`
+	Trace  models.Trace `json:"trace"`
+	Labels *Labels      `json:"labels,omitempty"`
+}
+
+type Dataset struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Cases       []Case `json:"cases"`
+}
+
+// Summary describes a dataset without its traces.
+type Summary struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Cases       int    `json:"cases"`
+	Labeled     int    `json:"labeled"`
+}
+
+func (d *Dataset) Summary() Summary {
+	s := Summary{Name: d.Name, Description: d.Description, Cases: len(d.Cases)}
+	for _, c := range d.Cases {
+		if c.Labels != nil {
+			s.Labeled++
+		}
+	}
+	return s
+}
+
+// Services returns every service named in the dataset's traces and labels,
+// the vocabulary hallucinated services are recognized from.
+func (d *Dataset) Services() []string {
+	seen := make(map[string]bool)
+	for _, c := range d.Cases {
+		for _, s := range TraceServices(c.Trace) {
+			seen[s] = true
+		}
+		if c.Labels != nil {
+			seen[c.Labels.RootCauseService] = true
+			for _, s := range c.Labels.AffectedServices {
+				seen[s] = true
+			}
+		}
+	}
+	delete(seen, "")
+
+	services := make([]string, 0, len(seen))
+	for s := range seen {
+		services = append(services, s)
+	}
+	sort.Strings(services)
+	return services
+}
+
+// Load reads a dataset file: a .json file holding a Dataset (or a bare
+// array of cases), or a .jsonl file with one case per line. The dataset is
+// named after the file unless it names itself.
+func Load(path string) (*Dataset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var d Dataset
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl":
+		scanner := bufio.NewScanner(strings.NewReader(string(data)))
+		scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+			var c Case
+			if err := json.Unmarshal([]byte(text), &c); err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+			}
+			d.Cases = append(d.Cases, c)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	default:
+		trimmed := strings.TrimSpace(string(data))
+		if strings.HasPrefix(trimmed, "[") {
+			err = json.Unmarshal(data, &d.Cases)
+		} else {
+			err = json.Unmarshal(data, &d)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	if d.Name == "" {
+		d.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := d.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &d, nil
+}
+
+// LoadDir loads every .json and .jsonl dataset under dir by name. A missing
+// dir holds no datasets.
+func LoadDir(dir string) (map[string]*Dataset, error) {
+	datasets := make(map[string]*Dataset)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if d.IsDir() || (ext != ".json" && ext != ".jsonl") {
+			return nil
+		}
+		dataset, err := Load(path)
+		if err != nil {
+			return err
+		}
+		if _, ok := datasets[dataset.Name]; ok {
+			return fmt.Errorf("duplicate dataset name %q in %s", dataset.Name, path)
+		}
+		datasets[dataset.Name] = dataset
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return datasets, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load datasets: %w", err)
+	}
+	return datasets, nil
+}
+
+// validate names unnamed cases and checks that labels refer to what is in
+// the trace, so a typo in a label cannot silently fail every run.
+func (d *Dataset) validate() error {
+	if len(d.Cases) == 0 {
+		return errors.New("dataset has no cases")
+	}
+	for i := range d.Cases {
+		c := &d.Cases[i]
+		if c.ID == "" {
+			c.ID = c.Trace.TraceID
+		}
+		if c.ID == "" {
+			c.ID = fmt.Sprintf("case-%d", i+1)
+		}
+		if c.Labels == nil {
+			continue
+		}
+
+		services := make(map[string]bool)
+		for _, s := range TraceServices(c.Trace) {
+			services[s] = true
+		}
+		if c.Labels.RootCauseService == "" {
+			return fmt.Errorf("case %s: root_cause_service is required in labels", c.ID)
+		}
+		if !services[c.Labels.RootCauseService] {
+			return fmt.Errorf("case %s: root cause service %q is not in the trace", c.ID, c.Labels.RootCauseService)
+		}
+
+		spans := make(map[string]bool, len(c.Trace.Spans))
+		for _, s := range c.Trace.Spans {
+			spans[s.SpanID] = true
+		}
+		for _, id := range c.Labels.RootCauseSpanIDs {
+			if !spans[id] {
+				return fmt.Errorf("case %s: root cause span %q is not in the trace", c.ID, id)
+			}
+		}
+	}
+	return nil
+}
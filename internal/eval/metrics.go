@@ -0,0 +1,202 @@
+package eval
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+// Metrics are deterministic scores of one explanation.
+type Metrics struct {
+	// Labeled reports whether ground truth was used. Without it the root
+	// cause is taken to be the service of the most severe fact, and the
+	// relevant services are those named in facts.
+	Labeled          bool   `json:"labeled"`
+	RootCauseService string `json:"root_cause_service,omitempty"`
+	RootCauseHit     bool   `json:"root_cause_hit"`
+	// RootCauseSpanHit reports whether a labeled root cause span ID was
+	// cited; it is unset when no spans are labeled.
+	RootCauseSpanHit  *bool    `json:"root_cause_span_hit,omitempty"`
+	MentionedServices []string `json:"mentioned_services"`
+	// ServicePrecision is the share of mentioned services that are
+	// relevant, 0 when none are mentioned. ServiceRecall is the share of
+	// relevant services mentioned, 1 when none are relevant.
+	ServicePrecision float64 `json:"service_precision"`
+	ServiceRecall    float64 `json:"service_recall"`
+	// HallucinatedServices are mentioned services that are not in the trace.
+	HallucinatedServices []string `json:"hallucinated_services,omitempty"`
+}
+
+// serviceLike matches identifiers that read as service names, so services
+// absent from every known trace are still caught when invented.
+var serviceLike = regexp.MustCompile(`\b[a-z][a-z0-9]*(?:[-_.][a-z0-9]+)*[-_](?:service|svc|api|db|database|gateway|server|worker|queue|cache|proxy)\b`)
+
+var severityRank = map[string]int{"critical": 2, "warning": 1}
+
+// TraceServices returns the services of a trace: span names, which the
+// analyzer reports facts against, and service.name attributes.
+func TraceServices(trace models.Trace) []string {
+	seen := make(map[string]bool)
+	var services []string
+	add := func(s string) {
+		if s != "" && !seen[s] {
+			seen[s] = true
+			services = append(services, s)
+		}
+	}
+	for i := range trace.Spans {
+		add(trace.Spans[i].Name)
+		if v, ok := trace.Spans[i].Attribute("service.name"); ok {
+			if s, ok := v.(string); ok {
+				add(s)
+			}
+		}
+	}
+	sort.Strings(services)
+	return services
+}
+
+// Score measures an explanation of a trace against its labels, or against
+// its facts when labels is nil. vocabulary lists other known services so
+// mentions of ones missing from this trace are reported as hallucinated.
+func Score(trace models.Trace, facts []models.SymbolicFact, labels *Labels, explanation string, vocabulary []string) Metrics {
+	m := Metrics{Labeled: labels != nil}
+	text := strings.ToLower(explanation)
+
+	inTrace := make(map[string]bool)
+	for _, s := range TraceServices(trace) {
+		inTrace[strings.ToLower(s)] = true
+	}
+
+	relevant := make(map[string]bool)
+	if labels != nil {
+		m.RootCauseService = labels.RootCauseService
+		relevant[strings.ToLower(labels.RootCauseService)] = true
+		for _, s := range labels.AffectedServices {
+			relevant[strings.ToLower(s)] = true
+		}
+		if len(labels.RootCauseSpanIDs) > 0 {
+			hit := false
+			for _, id := range labels.RootCauseSpanIDs {
+				if strings.Contains(explanation, id) {
+					hit = true
+					break
+				}
+			}
+			m.RootCauseSpanHit = &hit
+		}
+	} else {
+		best := -1
+		for _, f := range facts {
+			if f.Service == "" {
+				continue
+			}
+			relevant[strings.ToLower(f.Service)] = true
+			if rank := severityRank[f.Severity]; rank > best {
+				best = rank
+				m.RootCauseService = f.Service
+			}
+		}
+	}
+	if m.RootCauseService != "" {
+		m.RootCauseHit = mentions(text, strings.ToLower(m.RootCauseService))
+	}
+
+	mentioned := mentionedServices(text, inTrace, vocabulary)
+	found := 0
+	for s := range mentioned {
+		m.MentionedServices = append(m.MentionedServices, s)
+		if relevant[s] {
+			found++
+		}
+		if !inTrace[s] {
+			m.HallucinatedServices = append(m.HallucinatedServices, s)
+		}
+	}
+	sort.Strings(m.MentionedServices)
+	sort.Strings(m.HallucinatedServices)
+	if m.MentionedServices == nil {
+		m.MentionedServices = []string{}
+	}
+
+	if len(mentioned) > 0 {
+		m.ServicePrecision = float64(found) / float64(len(mentioned))
+	}
+	m.ServiceRecall = 1
+	if len(relevant) > 0 {
+		m.ServiceRecall = float64(found) / float64(len(relevant))
+	}
+	return m
+}
+
+// mentionedServices returns the services lowercase text names: those of
+// the trace, those in vocabulary and any identifier that reads as one.
+func mentionedServices(text string, inTrace map[string]bool, vocabulary []string) map[string]bool {
+	candidates := make(map[string]bool)
+	for s := range inTrace {
+		candidates[s] = true
+	}
+	for _, s := range vocabulary {
+		candidates[strings.ToLower(s)] = true
+	}
+	for _, s := range serviceLike.FindAllString(text, -1) {
+		candidates[s] = true
+	}
+
+	mentioned := make(map[string]bool)
+	for s := range candidates {
+		if s != "" && mentions(text, s) {
+			mentioned[s] = true
+		}
+	}
+	// A name contained in a longer mentioned name, like "payment" in
+	// "payment-service", is not a mention of its own.
+	for s := range mentioned {
+		for other := range mentioned {
+			if other != s && strings.Contains(other, s) && !mentionedAlone(text, s, other) {
+				delete(mentioned, s)
+				break
+			}
+		}
+	}
+	return mentioned
+}
+
+// mentions reports whether name occurs in text as a whole word. Both are
+// lowercase.
+func mentions(text, name string) bool {
+	return mentionIndex(text, name) >= 0
+}
+
+// mentionIndex returns the offset of the first whole-word occurrence of
+// name in text, or -1.
+func mentionIndex(text, name string) int {
+	for i := 0; ; {
+		j := strings.Index(text[i:], name)
+		if j < 0 {
+			return -1
+		}
+		start, end := i+j, i+j+len(name)
+		if boundary(text, start-1) && boundary(text, end) {
+			return start
+		}
+		i = start + 1
+	}
+}
+
+// mentionedAlone reports whether name occurs in text other than inside
+// longer.
+func mentionedAlone(text, name, longer string) bool {
+	return mentions(strings.ReplaceAll(text, longer, ""), name)
+}
+
+func boundary(text string, i int) bool {
+	if i < 0 || i >= len(text) {
+		return true
+	}
+	r := rune(text[i])
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_'
+}
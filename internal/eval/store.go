@@ -0,0 +1,128 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+	"github.com/google/uuid"
+)
+
+// Record is a stored evaluation of an explanation of a trace.
+type Record struct {
+	ID           string             `json:"id"`
+	TraceID      string             `json:"trace_id"`
+	Explanation  string             `json:"explanation"`
+	Evaluation   *models.Evaluation `json:"evaluation"`
+	Metrics      Metrics            `json:"metrics"`
+	ConnectionID string             `json:"connection_id,omitempty"`
+	// Prompt is the judge template version used.
+	Prompt    *models.PromptRef `json:"prompt,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Store keeps evaluations by trace in memory and, when a directory is set,
+// persists those of each trace as <trace id>.json so they survive restarts.
+type Store struct {
+	mu      sync.RWMutex
+	records map[string][]Record
+	dir     string
+}
+
+// NewStore loads the evaluations found in dir. An empty dir keeps them in
+// memory only.
+func NewStore(dir string) (*Store, error) {
+	s := &Store{
+		records: make(map[string][]Record),
+		dir:     dir,
+	}
+	if dir == "" {
+		return s, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create evaluation directory: %w", err)
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("Skipping evaluation file %s: %v", path, err)
+			continue
+		}
+		var records []Record
+		if err := json.Unmarshal(data, &records); err != nil {
+			log.Printf("Skipping evaluation file %s: %v", path, err)
+			continue
+		}
+		if len(records) > 0 {
+			s.records[records[0].TraceID] = records
+		}
+	}
+	return s, nil
+}
+
+// Add stores an evaluation and returns it with its ID assigned.
+func (s *Store) Add(r Record) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r.ID = uuid.New().String()
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	s.records[r.TraceID] = append(s.records[r.TraceID], r)
+	return r, s.save(r.TraceID)
+}
+
+// List returns the evaluations of a trace, oldest first, or of every trace
+// when traceID is empty.
+func (s *Store) List(traceID string) []Record {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if traceID != "" {
+		return append([]Record{}, s.records[traceID]...)
+	}
+	records := []Record{}
+	for _, rs := range s.records {
+		records = append(records, rs...)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].CreatedAt.Before(records[j].CreatedAt) })
+	return records
+}
+
+// save writes the evaluations of a trace atomically; callers hold the lock.
+func (s *Store) save(traceID string) error {
+	if s.dir == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(s.records[traceID])
+	if err != nil {
+		return err
+	}
+	path := s.path(traceID)
+	if err := os.WriteFile(path+".tmp", data, 0o600); err != nil {
+		return fmt.Errorf("failed to persist evaluation: %w", err)
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+func (s *Store) path(traceID string) string {
+	name := strings.ReplaceAll(traceID, string(filepath.Separator), "_")
+	if name == "" {
+		name = "_"
+	}
+	return filepath.Join(s.dir, name+".json")
+}
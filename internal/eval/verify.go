@@ -0,0 +1,368 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+// Claim kinds checked by Verify.
+const (
+	ClaimService    = "service"
+	ClaimSpanID     = "span_id"
+	ClaimError      = "error"
+	ClaimStatusCode = "status_code"
+	ClaimLatency    = "latency"
+	ClaimPercentage = "percentage"
+)
+
+// Claim is something an explanation cites that the trace can confirm: a
+// service, span ID, quoted error message, status code, latency or
+// percentage.
+type Claim struct {
+	Kind string `json:"kind"`
+	Text string `json:"text"`
+	// Offset is the byte offset of the claim in the explanation, or -1
+	// when it cannot be located there.
+	Offset    int  `json:"offset"`
+	Supported bool `json:"supported"`
+	// Evidence names what in the trace or facts supports the claim.
+	Evidence string `json:"evidence,omitempty"`
+}
+
+// Verification is the result of checking an explanation against the
+// analysis it explains. Claims are in order of appearance.
+type Verification struct {
+	Claims      []Claim `json:"claims"`
+	Unsupported int     `json:"unsupported"`
+}
+
+var (
+	spanIDLike = regexp.MustCompile(`\b[0-9a-f]{8,32}\b`)
+	quoted     = regexp.MustCompile("\"([^\"\\n]{3,200})\"|`([^`\\n]{3,200})`|“([^”\\n]{3,200})”")
+	// errorLike picks the quoted strings that read as error messages
+	// rather than names or commands.
+	errorLike  = regexp.MustCompile(`(?i)error|exception|fail|timeout|timed out|refused|denied|unavailable|exceeded|invalid|not found|panic|reset|deadline|unable|cannot|unreachable|rejected|overload`)
+	statusCode = regexp.MustCompile(`(?i)\b(?:http|status(?:\s+code)?)\s*[:=]?\s*([1-5]\d\d)\b`)
+	durationRe = regexp.MustCompile(`(?i)\b(\d[\d,]*(?:\.\d+)?)\s*(ms|milliseconds?|s|secs?|seconds?|mins?|minutes?)\b`)
+	percentRe  = regexp.MustCompile(`\b(\d+(?:\.\d+)?)\s*%`)
+)
+
+// source is a piece of text from the analysis that can support a claim.
+type source struct {
+	label string
+	text  string
+}
+
+// measure is a known value and where it comes from.
+type measure struct {
+	value float64
+	label string
+}
+
+// evidence is what an explanation may draw on: the trace, its facts and
+// the system context.
+type evidence struct {
+	sources     []source
+	lower       []string
+	spanIDs     map[string]string
+	services    map[string]string
+	durationsMs []measure
+	percents    []measure
+}
+
+// Verify extracts the claims of an explanation and checks each against
+// the trace's spans and attributes, the symbolic facts, system health,
+// metric anomalies, recent changes, similar incidents and runbook
+// sections, i.e. everything the prompt showed. vocabulary lists other known
+// services so that naming one missing from this trace counts as a claim.
+// A JSON explanation (format=json) is checked field by field.
+func Verify(analysis models.TraceAnalysis, explanation string, vocabulary []string) Verification {
+	ev := gatherEvidence(analysis)
+	text := explanation
+	var claims []Claim
+
+	var structured models.StructuredExplanation
+	if trimmed := strings.TrimSpace(explanation); strings.HasPrefix(trimmed, "{") && json.Unmarshal([]byte(trimmed), &structured) == nil {
+		parts := append([]string{structured.RootCause.Service}, structured.AffectedServices...)
+		parts = append(parts, structured.RootCause.Description)
+		text = strings.Join(append(parts, structured.Remediation...), "\n")
+		for _, id := range structured.EvidenceSpanIDs {
+			claims = append(claims, ev.checkSpanID(id, strings.Index(explanation, id)))
+		}
+	}
+	lower := strings.ToLower(text)
+
+	inTrace := make(map[string]bool, len(ev.services))
+	for s := range ev.services {
+		inTrace[s] = true
+	}
+	for s := range mentionedServices(lower, inTrace, vocabulary) {
+		c := Claim{Kind: ClaimService, Text: s, Offset: mentionIndex(lower, s)}
+		c.Evidence, c.Supported = ev.services[s]
+		claims = append(claims, c)
+	}
+
+	for _, loc := range spanIDLike.FindAllStringIndex(lower, -1) {
+		id := lower[loc[0]:loc[1]]
+		if strings.ContainsAny(id, "0123456789") && strings.ContainsAny(id, "abcdef") && boundary(lower, loc[0]-1) && boundary(lower, loc[1]) {
+			claims = append(claims, ev.checkSpanID(text[loc[0]:loc[1]], loc[0]))
+		}
+	}
+
+	for _, m := range quoted.FindAllStringSubmatchIndex(text, -1) {
+		for g := 2; g < len(m); g += 2 {
+			if m[g] < 0 {
+				continue
+			}
+			msg := strings.Trim(text[m[g]:m[g+1]], " .,;:")
+			if !errorLike.MatchString(msg) {
+				continue
+			}
+			c := Claim{Kind: ClaimError, Text: msg, Offset: m[g]}
+			c.Evidence, c.Supported = ev.find(func(s string) bool { return strings.Contains(s, strings.ToLower(msg)) })
+			claims = append(claims, c)
+		}
+	}
+
+	for _, m := range statusCode.FindAllStringSubmatchIndex(text, -1) {
+		code := text[m[2]:m[3]]
+		c := Claim{Kind: ClaimStatusCode, Text: code, Offset: m[2]}
+		c.Evidence, c.Supported = ev.find(func(s string) bool { return mentions(s, code) })
+		claims = append(claims, c)
+	}
+
+	for _, m := range durationRe.FindAllStringSubmatchIndex(text, -1) {
+		value, decimals, ok := parseNumber(text[m[2]:m[3]])
+		if !ok {
+			continue
+		}
+		unit := unitMs(text[m[4]:m[5]])
+		c := Claim{Kind: ClaimLatency, Text: text[m[0]:m[1]], Offset: m[0]}
+		c.Evidence, c.Supported = matchesAny(value*unit, decimals, unit, ev.durationsMs)
+		claims = append(claims, c)
+	}
+
+	for _, m := range percentRe.FindAllStringSubmatchIndex(text, -1) {
+		value, decimals, ok := parseNumber(text[m[2]:m[3]])
+		if !ok {
+			continue
+		}
+		c := Claim{Kind: ClaimPercentage, Text: text[m[0]:m[1]], Offset: m[0]}
+		c.Evidence, c.Supported = matchesAny(value, decimals, 1, ev.percents)
+		claims = append(claims, c)
+	}
+
+	// Offsets into the fields of a JSON explanation do not point into it.
+	if text != explanation {
+		for i := range claims {
+			if claims[i].Kind != ClaimSpanID {
+				claims[i].Offset = indexFold(explanation, claims[i].Text)
+			}
+		}
+	}
+	return summarize(claims)
+}
+
+// summarize drops repeated claims, orders them by offset and counts the
+// unsupported ones.
+func summarize(claims []Claim) Verification {
+	v := Verification{Claims: []Claim{}}
+	seen := make(map[string]bool)
+	for _, c := range claims {
+		key := c.Kind + "\x00" + strings.ToLower(c.Text)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		v.Claims = append(v.Claims, c)
+		if !c.Supported {
+			v.Unsupported++
+		}
+	}
+	sort.SliceStable(v.Claims, func(i, j int) bool {
+		oi, oj := v.Claims[i].Offset, v.Claims[j].Offset
+		if oi < 0 || oj < 0 {
+			return oi >= 0 && oj < 0
+		}
+		return oi < oj
+	})
+	return v
+}
+
+func gatherEvidence(analysis models.TraceAnalysis) *evidence {
+	trace := analysis.Trace
+	ev := &evidence{
+		spanIDs:  map[string]string{strings.ToLower(trace.TraceID): "trace ID"},
+		services: make(map[string]string),
+	}
+	add := func(label, text string) {
+		if text != "" {
+			ev.sources = append(ev.sources, source{label, text})
+		}
+	}
+	addService := func(name, label string) {
+		if _, ok := ev.services[strings.ToLower(name)]; !ok && name != "" {
+			ev.services[strings.ToLower(name)] = label
+		}
+	}
+
+	errorSpans := 0
+	for i := range trace.Spans {
+		span := &trace.Spans[i]
+		ev.spanIDs[strings.ToLower(span.SpanID)] = fmt.Sprintf("span %s (%s)", span.SpanID, span.Name)
+		addService(span.Name, "span "+span.SpanID)
+		ev.durationsMs = append(ev.durationsMs, measure{span.LatencyMs(), fmt.Sprintf("span %s latency", span.SpanID)})
+		if span.Status.Code == "ERROR" {
+			errorSpans++
+		}
+
+		add(fmt.Sprintf("span %s name", span.SpanID), span.Name)
+		add(fmt.Sprintf("span %s status", span.SpanID), span.Status.Message)
+		for _, a := range span.Attributes {
+			value := fmt.Sprint(a.Value)
+			add(fmt.Sprintf("span %s attribute %s", span.SpanID, a.Key), value)
+			if a.Key == "service.name" {
+				addService(value, fmt.Sprintf("span %s service.name", span.SpanID))
+			}
+			if n, ok := a.Value.(float64); ok && timeLike(a.Key) {
+				label := fmt.Sprintf("span %s attribute %s", span.SpanID, a.Key)
+				ev.durationsMs = append(ev.durationsMs, measure{n, label})
+				if strings.HasSuffix(a.Key, "_s") || strings.HasSuffix(a.Key, "seconds") {
+					ev.durationsMs = append(ev.durationsMs, measure{n * 1000, label})
+				}
+			}
+		}
+		for _, r := range span.ResourceNames {
+			add(fmt.Sprintf("span %s resource", span.SpanID), r)
+		}
+	}
+	if len(trace.Spans) > 0 {
+		start, end := trace.TimeWindow()
+		ev.durationsMs = append(ev.durationsMs, measure{float64(end.Sub(start).Microseconds()) / 1000.0, "trace duration"})
+		ev.percents = append(ev.percents, measure{float64(errorSpans) / float64(len(trace.Spans)) * 100, "share of error spans"})
+	}
+	ev.percents = append(ev.percents, measure{analysis.SystemContext.RecentErrorRate * 100, "recent error rate"})
+
+	// Everything else the prompt showed counts as evidence too.
+	for _, name := range analysis.SystemContext.SlowestServices {
+		addService(name, "recently slow services")
+		add("recently slow services", name)
+	}
+	for _, f := range analysis.SymbolicFacts {
+		add("fact "+f.Type, f.Description)
+	}
+	for _, a := range analysis.MetricAnomalies {
+		label := fmt.Sprintf("%s anomaly of %s", a.Metric, a.Service)
+		addService(a.Service, label)
+		add(label, a.Description)
+	}
+	for _, c := range analysis.RecentChanges {
+		label := fmt.Sprintf("%s change %s", c.Kind, c.ID)
+		addService(c.Service, label)
+		add(label, c.Service)
+		add(label, c.Version)
+		add(label, c.Author)
+		add(label, c.Description)
+	}
+	for _, inc := range analysis.SimilarIncidents {
+		label := "similar incident " + inc.TraceID
+		ev.spanIDs[strings.ToLower(inc.TraceID)] = label
+		add(label, inc.Explanation)
+	}
+	for _, rb := range analysis.Runbooks {
+		label := "runbook " + rb.File
+		if rb.Heading != "" {
+			label += " > " + rb.Heading
+		}
+		add(label, rb.File)
+		add(label, rb.Heading)
+		add(label, rb.Content)
+	}
+
+	for _, s := range ev.sources {
+		ev.lower = append(ev.lower, strings.ToLower(s.text))
+		for _, m := range durationRe.FindAllStringSubmatch(s.text, -1) {
+			if v, _, ok := parseNumber(m[1]); ok {
+				ev.durationsMs = append(ev.durationsMs, measure{v * unitMs(m[2]), s.label})
+			}
+		}
+		for _, m := range percentRe.FindAllStringSubmatch(s.text, -1) {
+			if v, _, ok := parseNumber(m[1]); ok {
+				ev.percents = append(ev.percents, measure{v, s.label})
+			}
+		}
+	}
+	return ev
+}
+
+// find returns the label of the first source whose lowercase text
+// satisfies match.
+func (ev *evidence) find(match func(string) bool) (string, bool) {
+	for i, s := range ev.lower {
+		if match(s) {
+			return ev.sources[i].label, true
+		}
+	}
+	return "", false
+}
+
+func (ev *evidence) checkSpanID(id string, offset int) Claim {
+	c := Claim{Kind: ClaimSpanID, Text: id, Offset: offset}
+	c.Evidence, c.Supported = ev.spanIDs[strings.ToLower(id)]
+	return c
+}
+
+func timeLike(key string) bool {
+	key = strings.ToLower(key)
+	return strings.Contains(key, "duration") || strings.Contains(key, "latency") || strings.Contains(key, "timeout") || strings.HasSuffix(key, "_ms")
+}
+
+// parseNumber reads a number as written, with its decimal places.
+func parseNumber(s string) (float64, int, bool) {
+	s = strings.ReplaceAll(s, ",", "")
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	decimals := 0
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		decimals = len(s) - i - 1
+	}
+	return v, decimals, true
+}
+
+func unitMs(unit string) float64 {
+	switch u := strings.ToLower(unit); {
+	case u == "ms" || strings.HasPrefix(u, "milli"):
+		return 1
+	case strings.HasPrefix(u, "m"):
+		return 60000
+	default:
+		return 1000
+	}
+}
+
+// matchesAny returns the label of a known value the claimed one matches,
+// allowing for the rounding its written precision implies and 5% slack.
+// unit scales that precision to the known values' unit.
+func matchesAny(claimed float64, decimals int, unit float64, known []measure) (string, bool) {
+	rounding := 0.5 * math.Pow(10, -float64(decimals)) * unit
+	for _, k := range known {
+		if math.Abs(claimed-k.value) <= math.Max(rounding, 0.05*math.Abs(k.value)) {
+			return k.label, true
+		}
+	}
+	return "", false
+}
+
+// indexFold is strings.Index ignoring ASCII case.
+func indexFold(s, substr string) int {
+	return strings.Index(strings.ToLower(s), strings.ToLower(substr))
+}
@@ -0,0 +1,354 @@
+// Package experiment runs prompt-strategy experiments: variants of
+// template, provider, model and parameters are run against a dataset of
+// traces, scored, and compared.
+package experiment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gigikoneti/tracemind/internal/analyzer"
+	"github.com/gigikoneti/tracemind/internal/eval"
+	"github.com/gigikoneti/tracemind/internal/llm"
+	"github.com/gigikoneti/tracemind/internal/models"
+	"github.com/gigikoneti/tracemind/internal/prompt"
+	"github.com/google/uuid"
+)
+
+// Variant is one prompting strategy: a prompt template on a connection's
+// provider and model, with sampling parameters.
+type Variant struct {
+	Name     string `json:"name"`
+	Template string `json:"template,omitempty"`
+	// ConnectionID picks a registered connection; empty uses the default engine.
+	ConnectionID string `json:"connection_id,omitempty"`
+	// Model replaces the connection's model when set.
+	Model       string   `json:"model,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	MaxTokens   int      `json:"max_tokens,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+	SpanIDs     bool     `json:"span_ids,omitempty"`
+}
+
+func (v Variant) options() llm.ExplainOptions {
+	return llm.ExplainOptions{
+		Template:    v.Template,
+		Temperature: v.Temperature,
+		MaxTokens:   v.MaxTokens,
+		Seed:        v.Seed,
+		SpanIDs:     v.SpanIDs,
+	}
+}
+
+// Spec describes an experiment: every variant is run on every case of a
+// named dataset and on every unlabeled trace given inline.
+type Spec struct {
+	Name     string         `json:"name"`
+	Variants []Variant      `json:"variants"`
+	Dataset  string         `json:"dataset,omitempty"`
+	Traces   []models.Trace `json:"traces,omitempty"`
+	// JudgeConnectionID picks the judge's connection; empty uses the default engine.
+	JudgeConnectionID string `json:"judge_connection_id,omitempty"`
+	// JudgePasses is how many judge passes are aggregated per output.
+	JudgePasses int `json:"judge_passes,omitempty"`
+	// Concurrency bounds explanations in flight, up to the runner's limit.
+	Concurrency int `json:"concurrency,omitempty"`
+}
+
+type Status string
+
+const (
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusCanceled  Status = "canceled"
+)
+
+// DefaultRetain is how many finished experiments a runner keeps by default.
+const DefaultRetain = 50
+
+var (
+	ErrNotFound   = errors.New("experiment not found")
+	ErrNotRunning = errors.New("experiment is not running")
+)
+
+type Progress struct {
+	Completed int `json:"completed"`
+	Total     int `json:"total"`
+}
+
+type Experiment struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Status      Status     `json:"status"`
+	Spec        Spec       `json:"spec"`
+	Progress    Progress   `json:"progress"`
+	Results     []Result   `json:"results,omitempty"`
+	Report      *Report    `json:"report,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// Runner runs experiments in the background and keeps them in memory.
+type Runner struct {
+	Pipeline *analyzer.Pipeline
+	Usage    *llm.UsageTracker
+	// Concurrency is the most explanations in flight per experiment.
+	Concurrency int
+	// Retain is how many finished experiments are kept; the oldest are
+	// dropped as new ones start. Zero keeps them all.
+	Retain int
+
+	mu          sync.RWMutex
+	experiments map[string]*Experiment
+	cancels     map[string]context.CancelFunc
+}
+
+func NewRunner(pipeline *analyzer.Pipeline, usage *llm.UsageTracker, concurrency int) *Runner {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return &Runner{
+		Pipeline:    pipeline,
+		Usage:       usage,
+		Concurrency: concurrency,
+		Retain:      DefaultRetain,
+		experiments: make(map[string]*Experiment),
+		cancels:     make(map[string]context.CancelFunc),
+	}
+}
+
+// Start validates a spec and runs it in the background over cases, the
+// spec's dataset and traces, until it completes or is canceled. engines
+// holds the engine of each variant, in order; judge scores every output.
+func (r *Runner) Start(spec Spec, cases []eval.Case, engines []*llm.Engine, judge *llm.Engine) (Experiment, error) {
+	if len(spec.Variants) == 0 {
+		return Experiment{}, errors.New("at least one variant is required")
+	}
+	if len(cases) == 0 {
+		return Experiment{}, errors.New("at least one trace is required")
+	}
+	if len(engines) != len(spec.Variants) {
+		return Experiment{}, errors.New("every variant needs an engine")
+	}
+
+	seen := make(map[string]bool)
+	for i := range spec.Variants {
+		v := &spec.Variants[i]
+		if v.Name == "" {
+			v.Name = fmt.Sprintf("variant-%d", i+1)
+		}
+		if seen[v.Name] {
+			return Experiment{}, fmt.Errorf("duplicate variant name %q", v.Name)
+		}
+		seen[v.Name] = true
+		if v.Template != "" {
+			if _, ok := prompt.Get(v.Template); !ok {
+				return Experiment{}, fmt.Errorf("variant %s: unknown prompt template %q", v.Name, v.Template)
+			}
+		}
+	}
+
+	exp := &Experiment{
+		ID:        uuid.New().String(),
+		Name:      spec.Name,
+		Status:    StatusRunning,
+		Spec:      spec,
+		Progress:  Progress{Total: len(spec.Variants) * len(cases)},
+		CreatedAt: time.Now(),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r.mu.Lock()
+	r.evict()
+	r.experiments[exp.ID] = exp
+	r.cancels[exp.ID] = cancel
+	started := r.snapshot(exp, false)
+	r.mu.Unlock()
+
+	go r.run(ctx, exp.ID, spec, cases, engines, judge)
+	return started, nil
+}
+
+// Cancel stops a running experiment. Explanations in flight are abandoned,
+// and the experiment is reported on the runs that finished.
+func (r *Runner) Cancel(id string) (Experiment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	exp, ok := r.experiments[id]
+	if !ok {
+		return Experiment{}, ErrNotFound
+	}
+	cancel, ok := r.cancels[id]
+	if !ok {
+		return r.snapshot(exp, false), ErrNotRunning
+	}
+	cancel()
+	return r.snapshot(exp, false), nil
+}
+
+// evict drops the oldest finished experiments beyond Retain; the caller
+// holds r.mu.
+func (r *Runner) evict() {
+	if r.Retain <= 0 {
+		return
+	}
+	var finished []*Experiment
+	for _, exp := range r.experiments {
+		if exp.Status != StatusRunning {
+			finished = append(finished, exp)
+		}
+	}
+	if len(finished) <= r.Retain {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].CreatedAt.Before(finished[j].CreatedAt) })
+	for _, exp := range finished[:len(finished)-r.Retain] {
+		delete(r.experiments, exp.ID)
+	}
+}
+
+// Get returns an experiment with its results.
+func (r *Runner) Get(id string) (Experiment, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	exp, ok := r.experiments[id]
+	if !ok {
+		return Experiment{}, false
+	}
+	return r.snapshot(exp, true), true
+}
+
+// List returns experiments newest first, without their results.
+func (r *Runner) List() []Experiment {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	experiments := make([]Experiment, 0, len(r.experiments))
+	for _, exp := range r.experiments {
+		experiments = append(experiments, r.snapshot(exp, false))
+	}
+	sort.Slice(experiments, func(i, j int) bool {
+		return experiments[i].CreatedAt.After(experiments[j].CreatedAt)
+	})
+	return experiments
+}
+
+// snapshot copies an experiment; the caller holds r.mu. Traces are left
+// out of the spec since they can be large and the caller sent them.
+func (r *Runner) snapshot(exp *Experiment, withResults bool) Experiment {
+	out := *exp
+	out.Spec.Traces = nil
+	out.Results = nil
+	if withResults {
+		out.Results = append([]Result(nil), exp.Results...)
+	}
+	return out
+}
+
+func (r *Runner) run(ctx context.Context, id string, spec Spec, cases []eval.Case, engines []*llm.Engine, judge *llm.Engine) {
+	// Each trace is analyzed once so every variant sees the same facts.
+	analyses := make([]models.TraceAnalysis, len(cases))
+	for i, c := range cases {
+		analyses[i] = r.Pipeline.Analyze(ctx, c.Trace)
+	}
+	vocabulary := (&eval.Dataset{Cases: cases}).Services()
+
+	concurrency := spec.Concurrency
+	if concurrency <= 0 || concurrency > r.Concurrency {
+		concurrency = r.Concurrency
+	}
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for vi, v := range spec.Variants {
+		for ci, analysis := range analyses {
+			wg.Add(1)
+			go func(v Variant, engine *llm.Engine, c eval.Case, analysis models.TraceAnalysis) {
+				defer wg.Done()
+				select {
+				case sem <- struct{}{}:
+					defer func() { <-sem }()
+				case <-ctx.Done():
+					return
+				}
+
+				result := r.runOne(ctx, v, engine, judge, spec.JudgePasses, c, analysis, vocabulary)
+
+				r.mu.Lock()
+				defer r.mu.Unlock()
+				exp := r.experiments[id]
+				exp.Results = append(exp.Results, result)
+				exp.Progress.Completed++
+			}(v, engines[vi], cases[ci], analysis)
+		}
+	}
+	wg.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cancels[id]()
+	delete(r.cancels, id)
+	exp := r.experiments[id]
+	sort.SliceStable(exp.Results, func(i, j int) bool {
+		if exp.Results[i].Variant != exp.Results[j].Variant {
+			return variantIndex(spec, exp.Results[i].Variant) < variantIndex(spec, exp.Results[j].Variant)
+		}
+		return exp.Results[i].Case < exp.Results[j].Case
+	})
+	report := Summarize(spec.Variants, exp.Results)
+	now := time.Now()
+	exp.Report = &report
+	exp.Status = StatusCompleted
+	if ctx.Err() != nil {
+		exp.Status = StatusCanceled
+	}
+	exp.CompletedAt = &now
+	log.Printf("Experiment %s %s: %d runs", id, exp.Status, len(exp.Results))
+}
+
+func (r *Runner) runOne(ctx context.Context, v Variant, engine, judge *llm.Engine, judgePasses int, c eval.Case, analysis models.TraceAnalysis, vocabulary []string) Result {
+	result := Result{Variant: v.Name, Case: c.ID, TraceID: analysis.Trace.TraceID}
+
+	start := time.Now()
+	resp, err := engine.Explain(ctx, analysis, v.options(), func(string) {})
+	result.LatencyMs = float64(time.Since(start).Microseconds()) / 1000.0
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	r.Usage.Record(engine.ConnectionID(), "experiment", resp.Usage)
+
+	result.Output = resp.Content
+	result.Prompt = resp.Prompt
+	result.Served = resp.Served
+	result.Usage = resp.Usage
+	result.Metrics = eval.Score(analysis.Trace, analysis.SymbolicFacts, c.Labels, resp.Content, vocabulary)
+
+	evaluation, judgeResp, err := judge.EvaluateExplanation(ctx, analysis.Trace, analysis.SymbolicFacts, resp.Content, judgePasses)
+	if judgeResp != nil {
+		r.Usage.Record(judge.ConnectionID(), "experiment_judge", judgeResp.Usage)
+	}
+	if err != nil {
+		result.JudgeError = err.Error()
+		return result
+	}
+	result.Judge = evaluation
+	return result
+}
+
+func variantIndex(spec Spec, name string) int {
+	for i, v := range spec.Variants {
+		if v.Name == name {
+			return i
+		}
+	}
+	return len(spec.Variants)
+}
@@ -0,0 +1,174 @@
+package experiment
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// VariantSummary compares one variant across the dataset. Rates and means
+// are over runs that produced an explanation; judge figures only over runs
+// the judge scored. The root-cause hit rate and service precision and
+// recall are over labeled runs, with their Fact counterparts over the
+// unlabeled runs, scored against the facts instead; the span hit rate is
+// over cases with labeled spans.
+type VariantSummary struct {
+	Variant          string  `json:"variant"`
+	Runs             int     `json:"runs"`
+	Errors           int     `json:"errors"`
+	Labeled          int     `json:"labeled"`
+	MeanScore        float64 `json:"mean_score"`
+	Scored           int     `json:"scored"`
+	RootCauseHitRate float64 `json:"root_cause_hit_rate"`
+	SpanHitRate      float64 `json:"span_hit_rate"`
+	// FactRootCauseHitRate is the share of unlabeled runs naming the
+	// service of the most severe fact.
+	FactRootCauseHitRate float64 `json:"fact_root_cause_hit_rate"`
+	JudgeRootCauseRate   float64 `json:"judge_root_cause_rate"`
+	MeanCompleteness     float64 `json:"mean_completeness"`
+	MeanActionability    float64 `json:"mean_actionability"`
+	// MeanHallucination is the judge's hallucination criterion, where 10
+	// means no unsupported claims.
+	MeanHallucination    float64 `json:"mean_hallucination"`
+	ServicePrecision     float64 `json:"service_precision"`
+	ServiceRecall        float64 `json:"service_recall"`
+	FactServicePrecision float64 `json:"fact_service_precision"`
+	FactServiceRecall    float64 `json:"fact_service_recall"`
+	// HallucinationRate is the share of runs naming a service that is not
+	// in the trace.
+	HallucinationRate    float64 `json:"hallucination_rate"`
+	MeanLatencyMs        float64 `json:"mean_latency_ms"`
+	P95LatencyMs         float64 `json:"p95_latency_ms"`
+	MeanPromptTokens     float64 `json:"mean_prompt_tokens"`
+	MeanCompletionTokens float64 `json:"mean_completion_tokens"`
+	TotalTokens          int     `json:"total_tokens"`
+	TotalCostUSD         float64 `json:"total_cost_usd"`
+}
+
+type Report struct {
+	Variants []VariantSummary `json:"variants"`
+}
+
+// Summarize aggregates results per variant, in variant order.
+func Summarize(variants []Variant, results []Result) Report {
+	byVariant := make(map[string][]Result)
+	for _, r := range results {
+		byVariant[r.Variant] = append(byVariant[r.Variant], r)
+	}
+
+	report := Report{Variants: make([]VariantSummary, 0, len(variants))}
+	for _, v := range variants {
+		s := VariantSummary{Variant: v.Name}
+		var latencies []float64
+		spanLabeled := 0
+		for _, r := range byVariant[v.Name] {
+			s.Runs++
+			if r.Error != "" {
+				s.Errors++
+				continue
+			}
+			latencies = append(latencies, r.LatencyMs)
+			m := r.Metrics
+			if m.Labeled {
+				s.Labeled++
+				if m.RootCauseHit {
+					s.RootCauseHitRate++
+				}
+				s.ServicePrecision += m.ServicePrecision
+				s.ServiceRecall += m.ServiceRecall
+			} else {
+				if m.RootCauseHit {
+					s.FactRootCauseHitRate++
+				}
+				s.FactServicePrecision += m.ServicePrecision
+				s.FactServiceRecall += m.ServiceRecall
+			}
+			if m.RootCauseSpanHit != nil {
+				spanLabeled++
+				if *m.RootCauseSpanHit {
+					s.SpanHitRate++
+				}
+			}
+			if len(m.HallucinatedServices) > 0 {
+				s.HallucinationRate++
+			}
+			if r.Judge != nil {
+				s.Scored++
+				s.MeanScore += r.Judge.Score
+				s.MeanCompleteness += r.Judge.Criteria.Completeness
+				s.MeanActionability += r.Judge.Criteria.Actionability
+				s.MeanHallucination += r.Judge.Criteria.Hallucination
+				if r.Judge.RootCauseFound {
+					s.JudgeRootCauseRate++
+				}
+			}
+			s.MeanLatencyMs += r.LatencyMs
+			s.MeanPromptTokens += float64(r.Usage.PromptTokens)
+			s.MeanCompletionTokens += float64(r.Usage.CompletionTokens)
+			s.TotalTokens += r.Usage.TotalTokens
+			s.TotalCostUSD += r.Usage.EstimatedCostUSD
+		}
+
+		if labeled := float64(s.Labeled); labeled > 0 {
+			s.RootCauseHitRate /= labeled
+			s.ServicePrecision /= labeled
+			s.ServiceRecall /= labeled
+		}
+		if unlabeled := float64(len(latencies) - s.Labeled); unlabeled > 0 {
+			s.FactRootCauseHitRate /= unlabeled
+			s.FactServicePrecision /= unlabeled
+			s.FactServiceRecall /= unlabeled
+		}
+		if ok := float64(len(latencies)); ok > 0 {
+			s.HallucinationRate /= ok
+			s.MeanLatencyMs /= ok
+			s.MeanPromptTokens /= ok
+			s.MeanCompletionTokens /= ok
+			sort.Float64s(latencies)
+			s.P95LatencyMs = latencies[(len(latencies)*95+99)/100-1]
+		}
+		if scored := float64(s.Scored); scored > 0 {
+			s.MeanScore /= scored
+			s.MeanCompleteness /= scored
+			s.MeanActionability /= scored
+			s.MeanHallucination /= scored
+			s.JudgeRootCauseRate /= scored
+		}
+		if spanLabeled > 0 {
+			s.SpanHitRate /= float64(spanLabeled)
+		}
+		report.Variants = append(report.Variants, s)
+	}
+	return report
+}
+
+var csvHeader = []string{
+	"variant", "runs", "errors", "labeled", "mean_score", "scored", "root_cause_hit_rate", "span_hit_rate", "fact_root_cause_hit_rate",
+	"judge_root_cause_rate", "mean_completeness", "mean_actionability", "mean_hallucination",
+	"service_precision", "service_recall", "fact_service_precision", "fact_service_recall", "hallucination_rate", "mean_latency_ms", "p95_latency_ms", "mean_prompt_tokens", "mean_completion_tokens", "total_tokens", "total_cost_usd",
+}
+
+// WriteCSV writes one row per variant.
+func (r Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', 4, 64) }
+	for _, s := range r.Variants {
+		row := []string{
+			s.Variant, strconv.Itoa(s.Runs), strconv.Itoa(s.Errors), strconv.Itoa(s.Labeled), f(s.MeanScore), strconv.Itoa(s.Scored),
+			f(s.RootCauseHitRate), f(s.SpanHitRate), f(s.FactRootCauseHitRate), f(s.JudgeRootCauseRate),
+			f(s.MeanCompleteness), f(s.MeanActionability), f(s.MeanHallucination),
+			f(s.ServicePrecision), f(s.ServiceRecall), f(s.FactServicePrecision), f(s.FactServiceRecall), f(s.HallucinationRate),
+			f(s.MeanLatencyMs), f(s.P95LatencyMs), f(s.MeanPromptTokens), f(s.MeanCompletionTokens),
+			strconv.Itoa(s.TotalTokens), f(s.TotalCostUSD),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,24 @@
+package experiment
+
+import (
+	"github.com/gigikoneti/tracemind/internal/eval"
+	"github.com/gigikoneti/tracemind/internal/llm"
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+// Result is one variant's explanation of one case and its scores.
+type Result struct {
+	Variant   string            `json:"variant"`
+	Case      string            `json:"case"`
+	TraceID   string            `json:"trace_id"`
+	Output    string            `json:"output,omitempty"`
+	Prompt    *models.PromptRef `json:"prompt,omitempty"`
+	Served    *llm.ServedBy     `json:"served_by,omitempty"`
+	LatencyMs float64           `json:"latency_ms"`
+	Usage     llm.Usage         `json:"usage"`
+	Metrics   eval.Metrics      `json:"metrics"`
+	// Judge is the judge's evaluation, when it gave one.
+	Judge      *models.Evaluation `json:"judge,omitempty"`
+	JudgeError string             `json:"judge_error,omitempty"`
+	Error      string             `json:"error,omitempty"`
+}
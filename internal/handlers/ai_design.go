@@ -10,11 +10,13 @@ import (
 
 	"github.com/gigikoneti/tracemind/internal/llm"
 	"github.com/gigikoneti/tracemind/internal/models"
+	"github.com/gigikoneti/tracemind/internal/prompt"
 	"github.com/google/uuid"
 )
 
 type AIDesignHandler struct {
 	ConnectionStore *ConnectionStore
+	Usage           *llm.UsageTracker
 }
 
 func (h *AIDesignHandler) GenerateDesign(w http.ResponseWriter, r *http.Request) {
@@ -49,21 +51,28 @@ func (h *AIDesignHandler) GenerateDesign(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	engine, err := llm.NewEngineFromConnection(conn)
+	engine, err := h.ConnectionStore.NewEngine(conn)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to initialize LLM engine: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	systemPrompt := buildInfrastructurePrompt(req.Prompt)
+	designReq, ref, err := buildInfrastructurePrompt(req.Prompt)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to render prompt: %v", err), http.StatusInternalServerError)
+		return
+	}
 
-	response, err := engine.GenerateText(r.Context(), systemPrompt)
+	response, err := engine.GenerateText(r.Context(), designReq)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Design generation failed: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	design, err := parseDesignResponse(response, req.Prompt, conn)
+	response.Prompt = &ref
+	h.Usage.Record(engine.ConnectionID(), "design", response.Usage)
+
+	design, err := parseDesignResponse(response, req.Prompt)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to parse design: %v", err), http.StatusInternalServerError)
 		return
@@ -105,32 +114,39 @@ func (h *AIDesignHandler) GenerateDesignStream(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	engine, err := llm.NewEngineFromConnection(conn)
+	engine, err := h.ConnectionStore.NewEngine(conn)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to initialize LLM engine: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	designReq, ref, err := buildInfrastructurePrompt(req.Prompt)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to render prompt: %v", err), http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 
-	systemPrompt := buildInfrastructurePrompt(req.Prompt)
-
-	var fullResponse strings.Builder
-
-	err = engine.GenerateTextStream(r.Context(), systemPrompt, func(token string) {
-		fullResponse.WriteString(token)
-		fmt.Fprintf(w, "event: token\ndata: %s\n\n", token)
+	response, err := engine.GenerateTextStream(r.Context(), designReq, func(token string) {
+		writeSSE(w, "token", token)
 		w.(http.Flusher).Flush()
 	})
 
 	if err != nil {
-		fmt.Fprintf(w, "event: error\ndata: %s\n\n", err.Error())
+		writeSSE(w, "error", err.Error())
 		return
 	}
 
-	design, err := parseDesignResponse(fullResponse.String(), req.Prompt, conn)
+	response.Prompt = &ref
+	h.Usage.Record(engine.ConnectionID(), "design_stream", response.Usage)
+	writeProvider(w, response)
+	usageJSON, _ := json.Marshal(response.Usage)
+	fmt.Fprintf(w, "event: usage\ndata: %s\n\n", usageJSON)
+
+	design, err := parseDesignResponse(response, req.Prompt)
 	if err != nil {
 		fmt.Fprintf(w, "event: error\ndata: Failed to parse design: %s\n\n", err.Error())
 		return
@@ -141,10 +157,35 @@ func (h *AIDesignHandler) GenerateDesignStream(w http.ResponseWriter, r *http.Re
 	fmt.Fprintf(w, "event: done\ndata: [DONE]\n\n")
 }
 
-func buildInfrastructurePrompt(userPrompt string) string {
-	return fmt.Sprintf(`You are an expert infrastructure architect specializing in Kubernetes and cloud-native systems. Generate a complete infrastructure design in JSON format based on the user's requirements.
+// PromptInfrastructureDesign names the design prompt template, rendered
+// with an InfrastructurePromptData.
+const PromptInfrastructureDesign = "infrastructure_design"
 
-User Request: %s
+const infrastructureTemplate = `User Request: {{.Prompt}}
+
+Generate the complete infrastructure design now:`
+
+func init() {
+	prompt.Register(PromptInfrastructureDesign, infrastructureSystemPrompt, infrastructureTemplate, InfrastructurePromptData{})
+}
+
+// InfrastructurePromptData is what the design template is rendered with.
+type InfrastructurePromptData struct {
+	Prompt string
+}
+
+func buildInfrastructurePrompt(userPrompt string) (llm.GenerateRequest, models.PromptRef, error) {
+	system, user, ref, err := prompt.Render(PromptInfrastructureDesign, InfrastructurePromptData{Prompt: userPrompt})
+	if err != nil {
+		return llm.GenerateRequest{}, ref, err
+	}
+	req := llm.PromptRequest(user)
+	req.System = system
+	req.ResponseFormat = llm.ResponseFormatJSON
+	return req, ref, nil
+}
+
+const infrastructureSystemPrompt = `You are an expert infrastructure architect specializing in Kubernetes and cloud-native systems. Generate a complete infrastructure design in JSON format based on the user's requirements.
 
 Output Format (JSON):
 {
@@ -181,13 +222,10 @@ Rules:
    - Security (non-root users, read-only filesystems where appropriate)
 4. For monitoring: Include Prometheus ServiceMonitor if requested
 5. For databases: Include StatefulSets with persistent storage
-6. Return ONLY valid JSON, no markdown code blocks, no explanations
+6. Return ONLY valid JSON, no markdown code blocks, no explanations`
 
-Generate the complete infrastructure design now:`, userPrompt)
-}
-
-func parseDesignResponse(response, prompt string, conn models.AIConnection) (*models.InfrastructureDesign, error) {
-	response = strings.TrimSpace(response)
+func parseDesignResponse(resp *llm.GenerateResponse, userPrompt string) (*models.InfrastructureDesign, error) {
+	response := strings.TrimSpace(resp.Content)
 
 	response = strings.TrimPrefix(response, "```json")
 	response = strings.TrimPrefix(response, "```")
@@ -208,9 +246,10 @@ func parseDesignResponse(response, prompt string, conn models.AIConnection) (*mo
 
 	design.Metadata = models.DesignMeta{
 		GeneratedBy: "TraceMind AI Adapter",
-		Prompt:      prompt,
-		Provider:    string(conn.Provider),
-		Model:       getModelFromConfig(conn),
+		Prompt:      userPrompt,
+		Template:    resp.Prompt,
+		Provider:    string(resp.Served.Provider),
+		Model:       resp.Served.Model,
 		GeneratedAt: time.Now(),
 	}
 
@@ -223,13 +262,6 @@ func parseDesignResponse(response, prompt string, conn models.AIConnection) (*mo
 	return &design, nil
 }
 
-func getModelFromConfig(conn models.AIConnection) string {
-	if model, ok := conn.Config["model"].(string); ok {
-		return model
-	}
-	return "unknown"
-}
-
 func (h *AIDesignHandler) ValidateDesign(ctx context.Context, design *models.InfrastructureDesign) error {
 	if design.Name == "" {
 		return fmt.Errorf("design name is required")
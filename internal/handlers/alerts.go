@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gigikoneti/tracemind/internal/alerting"
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+type AlertHandler struct {
+	Responder *alerting.Responder
+}
+
+// AlertmanagerWebhook accepts Alertmanager webhook payloads. Analysis runs in
+// the background so Alertmanager is acknowledged immediately.
+func (h *AlertHandler) AlertmanagerWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var payload models.AlertmanagerWebhook
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	h.Responder.Handle(payload)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":   "accepted",
+		"received": len(payload.Alerts),
+	})
+}
+
+func (h *AlertHandler) ListAlerts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if fp := r.URL.Query().Get("fingerprint"); fp != "" {
+		analysis, ok := h.Responder.Store.Get(fp)
+		if !ok {
+			http.Error(w, "Alert not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(analysis)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.Responder.Store.List())
+}
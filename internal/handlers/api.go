@@ -1,19 +1,45 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"strings"
 
+	"github.com/gigikoneti/tracemind/internal/agent"
 	"github.com/gigikoneti/tracemind/internal/analyzer"
+	"github.com/gigikoneti/tracemind/internal/cache"
+	"github.com/gigikoneti/tracemind/internal/eval"
 	"github.com/gigikoneti/tracemind/internal/llm"
 	"github.com/gigikoneti/tracemind/internal/memory"
 	"github.com/gigikoneti/tracemind/internal/models"
+	"github.com/gigikoneti/tracemind/internal/notify"
+	"github.com/gigikoneti/tracemind/internal/prompt"
+	"github.com/gigikoneti/tracemind/internal/session"
 )
 
 type TraceHandler struct {
 	Engine *llm.Engine
-	Memory *memory.Store
+	// Connections lets callers pick a registered AI connection with connection_id.
+	Connections *ConnectionStore
+	Memory      *memory.Store
+	Pipeline    *analyzer.Pipeline
+	Notifier    *notify.Notifier
+	Usage       *llm.UsageTracker
+	// MapReduceConcurrency bounds parallel subtree explanations in mode=mapreduce.
+	MapReduceConcurrency int
+	// AgentSteps bounds tool-call rounds in mode=agent.
+	AgentSteps int
+	// Cache is optional; when set identical analyses are replayed or coalesced.
+	Cache *cache.Cache
+	// Sessions is optional; when set each analysis opens a follow-up conversation.
+	Sessions *session.Store
+	// Evaluations is optional; when set judge evaluations are kept per trace.
+	Evaluations *eval.Store
 }
 
 func (h *TraceHandler) AnalyzeTraceStream(w http.ResponseWriter, r *http.Request) {
@@ -23,6 +49,16 @@ func (h *TraceHandler) AnalyzeTraceStream(w http.ResponseWriter, r *http.Request
 	}
 
 	useStructured := r.URL.Query().Get("structured") != "false"
+	mode := r.URL.Query().Get("mode")
+	if mode != "" && mode != "single" && mode != "mapreduce" && mode != "agent" {
+		http.Error(w, "Invalid mode: use single, mapreduce or agent", http.StatusBadRequest)
+		return
+	}
+	jsonOutput := r.URL.Query().Get("format") == "json"
+	if jsonOutput && mode != "" && mode != "single" {
+		http.Error(w, "format=json is only supported with mode=single", http.StatusBadRequest)
+		return
+	}
 
 	var trace models.Trace
 	if err := json.NewDecoder(r.Body).Decode(&trace); err != nil {
@@ -30,9 +66,23 @@ func (h *TraceHandler) AnalyzeTraceStream(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	engine, status, err := h.engineFor(r.URL.Query().Get("connection_id"))
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
 	h.Memory.AddTrace(trace)
-	facts := analyzer.AnalyzeTrace(trace)
-	health := h.Memory.GetHealth()
+	analysis := h.Pipeline.Analyze(r.Context(), trace)
+	facts, health := analysis.SymbolicFacts, analysis.SystemContext
+
+	if h.Notifier != nil {
+		for _, f := range facts {
+			if f.Severity == "critical" {
+				h.Notifier.NotifyAsync(notify.FromFact(trace.TraceID, f))
+			}
+		}
+	}
 
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
@@ -41,24 +91,128 @@ func (h *TraceHandler) AnalyzeTraceStream(w http.ResponseWriter, r *http.Request
 
 	// Send initial metadata
 	initialData := map[string]interface{}{
-		"facts":  facts,
-		"health": health,
-		"trace":  trace,
+		"facts":            facts,
+		"health":           health,
+		"trace":            trace,
+		"metric_anomalies": analysis.MetricAnomalies,
+		"runbooks":         analysis.Runbooks,
+	}
+	var sessionID string
+	if h.Sessions != nil {
+		sess, err := h.Sessions.Create(analysis, r.URL.Query().Get("connection_id"))
+		if err != nil {
+			log.Printf("Failed to persist session %s: %v", sess.ID, err)
+		}
+		sessionID = sess.ID
+		initialData["session_id"] = sessionID
 	}
 	initialJSON, _ := json.Marshal(initialData)
 	fmt.Fprintf(w, "event: metadata\ndata: %s\n\n", initialJSON)
 	w.(http.Flusher).Flush()
 
-	err := h.Engine.ExplainTraceStream(r.Context(), trace, facts, health, useStructured, func(token string) {
-		fmt.Fprintf(w, "event: token\ndata: %s\n\n", token)
+	writeEvent := func(e cache.Event) {
+		writeSSE(w, e.Name, e.Data)
 		w.(http.Flusher).Flush()
-	})
+	}
 
-	if err != nil {
-		fmt.Fprintf(w, "event: error\ndata: %s\n\n", err.Error())
+	generate := func(ctx context.Context, emit func(cache.Event)) (*llm.GenerateResponse, error) {
+		onToken := func(token string) {
+			emit(cache.Event{Name: "token", Data: token})
+		}
+
+		switch {
+		case jsonOutput:
+			explanation, response, err := engine.ExplainTraceJSON(ctx, analysis, onToken, func(attempt int, problems []string) {
+				repairJSON, _ := json.Marshal(map[string]interface{}{"attempt": attempt, "problems": problems})
+				emit(cache.Event{Name: "repair", Data: string(repairJSON)})
+			})
+			if err != nil {
+				return nil, err
+			}
+			analysisJSON, _ := json.Marshal(explanation)
+			emit(cache.Event{Name: "analysis", Data: string(analysisJSON)})
+			return response, nil
+		case mode == "mapreduce":
+			return engine.ExplainTraceMapReduce(ctx, analysis, h.MapReduceConcurrency, func(p llm.MapReduceProgress) {
+				progressJSON, _ := json.Marshal(p)
+				emit(cache.Event{Name: "progress", Data: string(progressJSON)})
+			}, onToken)
+		case mode == "agent":
+			response, err := agent.Investigate(ctx, engine, analysis, h.Memory, h.AgentSteps, func(e llm.AgentEvent) {
+				eventJSON, _ := json.Marshal(e)
+				emit(cache.Event{Name: e.Type, Data: string(eventJSON)})
+			})
+			if err != nil {
+				return nil, err
+			}
+			onToken(response.Content)
+			return response, nil
+		default:
+			return engine.ExplainTraceStream(ctx, analysis, useStructured, onToken)
+		}
+	}
+
+	// Agent runs depend on live memory through their tools, so they are
+	// never reused.
+	var response *llm.GenerateResponse
+	source := cache.SourceMiss
+	if h.Cache != nil && mode != "agent" && r.URL.Query().Get("cache") != "false" {
+		cfg := engine.Config()
+		if mode == "" {
+			mode = "single"
+		}
+		key := cache.Fingerprint(analysis, jsonOutput,
+			fmt.Sprintf("prompts=%d", prompt.Revision()), engine.ConnectionID(), string(cfg.Provider), cfg.Model,
+			"mode="+mode, fmt.Sprintf("json=%t", jsonOutput), fmt.Sprintf("structured=%t", useStructured))
+		response, source, err = h.Cache.Do(r.Context(), key, generate, writeEvent)
+
+		cacheJSON, _ := json.Marshal(map[string]interface{}{"status": source, "key": key})
+		writeEvent(cache.Event{Name: "cache", Data: string(cacheJSON)})
 	} else {
-		fmt.Fprintf(w, "event: done\ndata: [DONE]\n\n")
+		response, err = generate(r.Context(), writeEvent)
+	}
+
+	if err != nil {
+		writeSSE(w, "error", err.Error())
+		return
+	}
+
+	if sessionID != "" {
+		if err := h.Sessions.Append(sessionID, models.SessionMessage{Role: string(llm.RoleAssistant), Content: response.Content, Prompt: response.Prompt}); err != nil {
+			log.Printf("Failed to record explanation in session %s: %v", sessionID, err)
+		}
 	}
+
+	if source == cache.SourceMiss && h.Pipeline.Similar != nil {
+		// What was retrieved for the trace says nothing about the failure
+		// itself, so it is left out when recognizing repeats.
+		incident := analysis
+		incident.SimilarIncidents = nil
+		fingerprint := cache.Fingerprint(incident, false)
+		go func(ctx context.Context, explanation string) {
+			if err := h.Pipeline.Similar.Add(ctx, analysis, explanation, fingerprint); err != nil {
+				log.Printf("Failed to index trace %s for similarity search: %v", trace.TraceID, err)
+			}
+		}(context.WithoutCancel(r.Context()), response.Content)
+	}
+
+	writeProvider(w, response)
+	// Replayed and coalesced requests cost nothing beyond the generation
+	// already recorded.
+	if source != cache.SourceMiss {
+		response.Usage = llm.Usage{}
+	}
+	h.Usage.Record(engine.ConnectionID(), "analyze", response.Usage)
+	usageJSON, _ := json.Marshal(response.Usage)
+	fmt.Fprintf(w, "event: usage\ndata: %s\n\n", usageJSON)
+
+	verification := eval.Verify(analysis, response.Content, h.knownServices())
+	if verification.Unsupported > 0 {
+		log.Printf("Explanation of trace %s makes %d unsupported claims", trace.TraceID, verification.Unsupported)
+	}
+	verificationJSON, _ := json.Marshal(verification)
+	fmt.Fprintf(w, "event: verification\ndata: %s\n\n", verificationJSON)
+	fmt.Fprintf(w, "event: done\ndata: [DONE]\n\n")
 }
 
 func (h *TraceHandler) Evaluate(w http.ResponseWriter, r *http.Request) {
@@ -68,9 +222,14 @@ func (h *TraceHandler) Evaluate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Trace       models.Trace          `json:"trace"`
-		Facts       []models.SymbolicFact `json:"facts"`
-		Explanation string                `json:"explanation"`
+		Trace        models.Trace          `json:"trace"`
+		Facts        []models.SymbolicFact `json:"facts"`
+		Explanation  string                `json:"explanation"`
+		ConnectionID string                `json:"connection_id"`
+		// Labels is optional ground truth for the deterministic metrics.
+		Labels *eval.Labels `json:"labels"`
+		// Passes runs the judge several times and aggregates the verdicts.
+		Passes int `json:"passes"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -78,13 +237,110 @@ func (h *TraceHandler) Evaluate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	score, err := h.Engine.EvaluateExplanation(r.Context(), req.Trace, req.Facts, req.Explanation)
+	engine, status, err := h.engineFor(req.ConnectionID)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	if req.Passes > llm.MaxJudgePasses {
+		http.Error(w, fmt.Sprintf("passes must be at most %d", llm.MaxJudgePasses), http.StatusBadRequest)
+		return
+	}
+
+	evaluation, score, err := engine.EvaluateExplanation(r.Context(), req.Trace, req.Facts, req.Explanation, req.Passes)
+	if score != nil {
+		h.Usage.Record(engine.ConnectionID(), "evaluate", score.Usage)
+	}
 	if err != nil {
 		http.Error(w, "Evaluation failed: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	metrics := eval.Score(req.Trace, req.Facts, req.Labels, req.Explanation, h.knownServices())
+
+	var id string
+	if h.Evaluations != nil {
+		record, err := h.Evaluations.Add(eval.Record{
+			TraceID:      req.Trace.TraceID,
+			Explanation:  req.Explanation,
+			Evaluation:   evaluation,
+			Metrics:      metrics,
+			ConnectionID: req.ConnectionID,
+			Prompt:       score.Prompt,
+		})
+		if err != nil {
+			log.Printf("Failed to save evaluation of trace %s: %v", req.Trace.TraceID, err)
+		}
+		id = record.ID
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
-	json.NewEncoder(w).Encode(map[string]string{"evaluation": score})
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":         id,
+		"evaluation": evaluation,
+		"metrics":    metrics,
+		"usage":      score.Usage,
+		"served_by":  score.Served,
+		"prompt":     score.Prompt,
+	})
+}
+
+// writeProvider reports which provider answered; behind a fallback chain it
+// also lists the attempts that failed first. The prompt template version
+// used follows when known.
+// writeSSE writes an event whose data may span several lines, one data
+// field per line, which clients join back with newlines.
+func writeSSE(w io.Writer, event, data string) {
+	fmt.Fprintf(w, "event: %s\n", event)
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(w, "data: %s\n", line)
+	}
+	fmt.Fprint(w, "\n")
+}
+
+func writeProvider(w http.ResponseWriter, response *llm.GenerateResponse) {
+	servedJSON, _ := json.Marshal(response.Served)
+	fmt.Fprintf(w, "event: provider\ndata: %s\n\n", servedJSON)
+	if response.Prompt != nil {
+		promptJSON, _ := json.Marshal(response.Prompt)
+		fmt.Fprintf(w, "event: prompt\ndata: %s\n\n", promptJSON)
+	}
+}
+
+// knownServices lists the services seen in any trace. They help recognize
+// ones an explanation mentions but its trace does not contain.
+func (h *TraceHandler) knownServices() []string {
+	var services []string
+	for service := range h.Memory.GetServiceStats() {
+		services = append(services, service)
+	}
+	return services
+}
+
+func (h *TraceHandler) engineFor(connectionID string) (*llm.Engine, int, error) {
+	return resolveEngine(h.Engine, h.Connections, connectionID)
+}
+
+// resolveEngine returns the engine for a registered connection, or the
+// default engine when no connection is requested.
+func resolveEngine(defaultEngine *llm.Engine, connections *ConnectionStore, connectionID string) (*llm.Engine, int, error) {
+	if connectionID == "" {
+		return defaultEngine, http.StatusOK, nil
+	}
+
+	if connections == nil {
+		return nil, http.StatusNotFound, errors.New("Connection not found")
+	}
+	conn, ok := connections.Get(connectionID)
+	if !ok {
+		return nil, http.StatusNotFound, errors.New("Connection not found")
+	}
+
+	engine, err := connections.NewEngine(conn)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("Failed to initialize LLM engine: %v", err)
+	}
+	return engine, http.StatusOK, nil
 }
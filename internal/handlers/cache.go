@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gigikoneti/tracemind/internal/cache"
+)
+
+type CacheHandler struct {
+	Cache *cache.Cache
+}
+
+// Stats reports explanation cache statistics on GET and empties the cache
+// on DELETE.
+func (h *CacheHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodDelete:
+		h.Cache.Purge()
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.Cache.Stats())
+}
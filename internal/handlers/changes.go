@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gigikoneti/tracemind/internal/memory"
+	"github.com/gigikoneti/tracemind/internal/models"
+	"github.com/google/uuid"
+)
+
+type ChangeHandler struct {
+	Memory *memory.Store
+}
+
+// Changes ingests change events on POST (a single event or an array) and
+// lists them on GET, optionally filtered by ?service= and ?since=<RFC3339>.
+func (h *ChangeHandler) Changes(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.ingest(w, r)
+	case http.MethodGet:
+		h.list(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *ChangeHandler) ingest(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var changes []models.ChangeEvent
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &changes)
+	} else {
+		var change models.ChangeEvent
+		err = json.Unmarshal(trimmed, &change)
+		changes = append(changes, change)
+	}
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	for i := range changes {
+		c := &changes[i]
+		if c.Service == "" {
+			http.Error(w, fmt.Sprintf("change %d: service is required", i), http.StatusBadRequest)
+			return
+		}
+		if c.Kind == "" {
+			c.Kind = "deployment"
+		}
+		if c.Timestamp.IsZero() {
+			c.Timestamp = time.Now()
+		}
+		c.ID = uuid.New().String()
+	}
+
+	for _, c := range changes {
+		h.Memory.AddChange(c)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(changes)
+}
+
+func (h *ChangeHandler) list(w http.ResponseWriter, r *http.Request) {
+	var since time.Time
+	if v := r.URL.Query().Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			http.Error(w, "since must be an RFC3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		since = t
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.Memory.Changes(r.URL.Query().Get("service"), since))
+}
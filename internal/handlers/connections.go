@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/gigikoneti/tracemind/internal/llm"
 	"github.com/gigikoneti/tracemind/internal/models"
@@ -57,8 +58,47 @@ func (s *ConnectionStore) Delete(id string) bool {
 	return false
 }
 
+// NewEngine builds the engine for a connection, resolving a fallback chain
+// to the engines of its member connections.
+func (s *ConnectionStore) NewEngine(conn models.AIConnection) (*llm.Engine, error) {
+	if conn.Provider != models.ProviderFallback {
+		return llm.NewEngineFromConnection(conn)
+	}
+
+	config, err := conn.GetFallbackConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]llm.FallbackMember, 0, len(config.Connections))
+	for _, m := range config.Connections {
+		member, ok := s.Get(m.ConnectionID)
+		if !ok {
+			return nil, fmt.Errorf("fallback connection %s not found", m.ConnectionID)
+		}
+		if member.Provider == models.ProviderFallback {
+			return nil, fmt.Errorf("fallback connection %s is itself a fallback chain", m.ConnectionID)
+		}
+		engine, err := llm.NewEngineFromConnection(member)
+		if err != nil {
+			return nil, fmt.Errorf("fallback connection %s: %w", m.ConnectionID, err)
+		}
+		// Validate has already checked the durations.
+		timeout, _ := time.ParseDuration(m.Timeout)
+		members = append(members, llm.FallbackMember{Engine: engine, Timeout: timeout})
+	}
+
+	retries := llm.DefaultFallbackRetries
+	if _, ok := conn.Config["max_retries"]; ok {
+		retries = config.MaxRetries
+	}
+	backoff, _ := time.ParseDuration(config.InitialBackoff)
+	return llm.NewFallbackEngine(conn.ID, members, retries, backoff)
+}
+
 type ConnectionHandler struct {
 	Store *ConnectionStore
+	Usage *llm.UsageTracker
 }
 
 func (h *ConnectionHandler) CreateConnection(w http.ResponseWriter, r *http.Request) {
@@ -120,7 +160,7 @@ func (h *ConnectionHandler) TestConnection(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	engine, err := llm.NewEngineFromConnection(conn)
+	engine, err := h.Store.NewEngine(conn)
 	if err != nil {
 		conn.Status = "error"
 		h.Store.Add(conn)
@@ -134,7 +174,7 @@ func (h *ConnectionHandler) TestConnection(w http.ResponseWriter, r *http.Reques
 	}
 
 	testPrompt := "Reply with 'OK' if you can read this."
-	response, err := engine.GenerateText(r.Context(), testPrompt)
+	response, err := engine.GenerateText(r.Context(), llm.PromptRequest(testPrompt))
 	if err != nil {
 		conn.Status = "error"
 		h.Store.Add(conn)
@@ -147,6 +187,8 @@ func (h *ConnectionHandler) TestConnection(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	h.Usage.Record(engine.ConnectionID(), "connection_test", response.Usage)
+
 	conn.Status = "connected"
 	h.Store.Add(conn)
 
@@ -154,7 +196,8 @@ func (h *ConnectionHandler) TestConnection(w http.ResponseWriter, r *http.Reques
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"status":   "connected",
 		"message":  "Connection successful",
-		"response": response,
+		"response": response.Content,
+		"usage":    response.Usage,
 	})
 }
 
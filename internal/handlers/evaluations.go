@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gigikoneti/tracemind/internal/eval"
+)
+
+type EvaluationHandler struct {
+	Store *eval.Store
+}
+
+// Evaluations lists stored judge evaluations, oldest first, optionally
+// only those of one trace (?trace_id=).
+func (h *EvaluationHandler) Evaluations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.Store.List(r.URL.Query().Get("trace_id")))
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gigikoneti/tracemind/internal/eval"
+	"github.com/gigikoneti/tracemind/internal/experiment"
+	"github.com/gigikoneti/tracemind/internal/llm"
+)
+
+type ExperimentHandler struct {
+	Runner      *experiment.Runner
+	Engine      *llm.Engine
+	Connections *ConnectionStore
+	// Datasets are the labeled datasets experiments can name, by name.
+	Datasets map[string]*eval.Dataset
+}
+
+// Experiments lists experiments on GET, and on POST starts one from an
+// experiment.Spec, returning it while it runs in the background.
+func (h *ExperimentHandler) Experiments(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(h.Runner.List())
+
+	case http.MethodPost:
+		var spec experiment.Spec
+		if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		var cases []eval.Case
+		if spec.Dataset != "" {
+			dataset, ok := h.Datasets[spec.Dataset]
+			if !ok {
+				http.Error(w, "Dataset not found", http.StatusNotFound)
+				return
+			}
+			cases = append(cases, dataset.Cases...)
+		}
+		for _, t := range spec.Traces {
+			cases = append(cases, eval.Case{ID: t.TraceID, Trace: t})
+		}
+
+		engines := make([]*llm.Engine, len(spec.Variants))
+		for i, v := range spec.Variants {
+			engine, status, err := h.variantEngine(v)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Variant %d: %v", i+1, err), status)
+				return
+			}
+			engines[i] = engine
+		}
+		judge, status, err := resolveEngine(h.Engine, h.Connections, spec.JudgeConnectionID)
+		if err != nil {
+			http.Error(w, "Judge: "+err.Error(), status)
+			return
+		}
+
+		exp, err := h.Runner.Start(spec, cases, engines, judge)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid experiment: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(exp)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Experiment returns an experiment with its progress and per-run results
+// (GET /api/experiments/{id}).
+func (h *ExperimentHandler) Experiment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	exp, ok := h.Runner.Get(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "Experiment not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(exp)
+}
+
+// Cancel stops a running experiment and returns it
+// (POST /api/experiments/{id}/cancel).
+func (h *ExperimentHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	exp, err := h.Runner.Cancel(r.PathValue("id"))
+	switch {
+	case errors.Is(err, experiment.ErrNotFound):
+		http.Error(w, "Experiment not found", http.StatusNotFound)
+		return
+	case errors.Is(err, experiment.ErrNotRunning):
+		http.Error(w, "Experiment is not running", http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(exp)
+}
+
+// Report returns the comparison of a completed experiment's variants as
+// JSON, or as CSV with ?format=csv (GET /api/experiments/{id}/report).
+func (h *ExperimentHandler) Report(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	exp, ok := h.Runner.Get(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "Experiment not found", http.StatusNotFound)
+		return
+	}
+	if exp.Report == nil {
+		http.Error(w, "Experiment is still running", http.StatusConflict)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=experiment-%s.csv", exp.ID))
+		exp.Report.WriteCSV(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(exp.Report)
+}
+
+// ListDatasets lists the labeled datasets loaded at startup (GET /api/datasets).
+func (h *ExperimentHandler) ListDatasets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	summaries := make([]eval.Summary, 0, len(h.Datasets))
+	for _, d := range h.Datasets {
+		summaries = append(summaries, d.Summary())
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Name < summaries[j].Name })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summaries)
+}
+
+// variantEngine resolves a variant's connection, swapping in its model when
+// one is set.
+func (h *ExperimentHandler) variantEngine(v experiment.Variant) (*llm.Engine, int, error) {
+	if v.Model == "" {
+		return resolveEngine(h.Engine, h.Connections, v.ConnectionID)
+	}
+	if v.ConnectionID == "" {
+		engine, err := llm.NewEngine(v.Model)
+		if err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("Failed to initialize LLM engine: %v", err)
+		}
+		return engine, http.StatusOK, nil
+	}
+
+	conn, ok := h.Connections.Get(v.ConnectionID)
+	if !ok {
+		return nil, http.StatusNotFound, errors.New("Connection not found")
+	}
+	// Copy the config so the registered connection keeps its model.
+	config := make(map[string]interface{}, len(conn.Config)+1)
+	for k, val := range conn.Config {
+		config[k] = val
+	}
+	config["model"] = v.Model
+	conn.Config = config
+
+	engine, err := h.Connections.NewEngine(conn)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("Failed to initialize LLM engine: %v", err)
+	}
+	return engine, http.StatusOK, nil
+}
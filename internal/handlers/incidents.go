@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gigikoneti/tracemind/internal/incident"
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+type IncidentHandler struct {
+	Store *incident.Store
+}
+
+func (h *IncidentHandler) ListIncidents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	status := models.IncidentStatus(r.URL.Query().Get("status"))
+	if status != "" && status != models.IncidentOpen && status != models.IncidentResolved {
+		http.Error(w, "status must be 'open' or 'resolved'", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.Store.List(status))
+}
+
+func (h *IncidentHandler) GetIncident(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	inc, ok := h.Store.Get(r.URL.Query().Get("id"))
+	if !ok {
+		http.Error(w, "Incident not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(inc)
+}
+
+func (h *IncidentHandler) ResolveIncident(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		IncidentID string `json:"incident_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !h.Store.Resolve(req.IncidentID) {
+		http.Error(w, "Open incident not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":  "success",
+		"message": "Incident resolved",
+	})
+}
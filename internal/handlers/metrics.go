@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gigikoneti/tracemind/internal/memory"
+	"github.com/gigikoneti/tracemind/internal/otlp"
+)
+
+type MetricsHandler struct {
+	Memory *memory.Store
+	// Cumulative turns cumulative counters and histograms into rates and
+	// means across exports; without it their points are dropped.
+	Cumulative *otlp.Cumulative
+}
+
+// IngestOTLP accepts OTLP/HTTP metrics exports in the JSON encoding.
+func (h *MetricsHandler) IngestOTLP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if ct := r.Header.Get("Content-Type"); ct != "" && !strings.HasPrefix(ct, "application/json") {
+		http.Error(w, "Only the OTLP JSON encoding (application/json) is supported", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	samples, err := otlp.ParseMetricsJSON(body, h.Cumulative)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid metrics payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	h.Memory.AddMetricSamples(samples)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"samples": len(samples),
+	})
+}
+
+func (h *MetricsHandler) ListSeries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var services []string
+	if svc := r.URL.Query().Get("service"); svc != "" {
+		services = append(services, svc)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.Memory.MetricSeries(services...))
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gigikoneti/tracemind/internal/prompt"
+)
+
+type PromptHandler struct{}
+
+// Prompts lists prompt templates with their versions on GET, and on POST
+// ({"name", "system", "user"}) adds a new version and makes it active.
+func (h *PromptHandler) Prompts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(prompt.List())
+
+	case http.MethodPost:
+		var req struct {
+			Name   string `json:"name"`
+			System string `json:"system"`
+			User   string `json:"user"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		created, err := prompt.Create(prompt.Template{Name: req.Name, System: req.System, User: req.User})
+		if err != nil && created.Version == 0 {
+			http.Error(w, fmt.Sprintf("Failed to create prompt: %v", err), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Prompt created but not saved: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(created)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Versions returns every version of a prompt, oldest first
+// (GET /api/prompts/{name}).
+func (h *PromptHandler) Versions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	versions := prompt.Versions(r.PathValue("name"))
+	if len(versions) == 0 {
+		http.Error(w, "Prompt not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(versions)
+}
+
+// Activate switches a prompt to an existing version, e.g. to roll back
+// (POST /api/prompts/{name}/activate with {"version"}).
+func (h *PromptHandler) Activate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Version int `json:"version"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	name := r.PathValue("name")
+	if err := prompt.Activate(name, req.Version); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":  "success",
+		"message": fmt.Sprintf("Prompt %s version %d activated", name, req.Version),
+	})
+}
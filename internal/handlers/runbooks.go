@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gigikoneti/tracemind/internal/runbook"
+)
+
+type RunbookHandler struct {
+	Store *runbook.Store
+}
+
+// Runbooks lists indexed runbooks on GET, indexes an uploaded markdown
+// runbook on POST ({"name", "content"}) and removes one on DELETE (?name=).
+func (h *RunbookHandler) Runbooks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(h.Store.List())
+
+	case http.MethodPost:
+		var req struct {
+			Name    string `json:"name"`
+			Content string `json:"content"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if req.Content == "" {
+			http.Error(w, "Content is required", http.StatusBadRequest)
+			return
+		}
+
+		info, err := h.Store.Add(req.Name, req.Content)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to add runbook: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(info)
+
+	case http.MethodDelete:
+		name := r.URL.Query().Get("name")
+		found, err := h.Store.Delete(name)
+		if !found {
+			http.Error(w, "Runbook not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to delete runbook file: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"status":  "success",
+			"message": "Runbook deleted",
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
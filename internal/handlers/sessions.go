@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gigikoneti/tracemind/internal/llm"
+	"github.com/gigikoneti/tracemind/internal/models"
+	"github.com/gigikoneti/tracemind/internal/session"
+)
+
+type SessionHandler struct {
+	Sessions    *session.Store
+	Engine      *llm.Engine
+	Connections *ConnectionStore
+	Usage       *llm.UsageTracker
+}
+
+// Session returns a conversation with its analyzed trace (GET /api/sessions/{id}).
+func (h *SessionHandler) Session(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sess, ok := h.Sessions.Get(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sess)
+}
+
+// Messages lists the turns of a session on GET, and on POST answers a
+// follow-up question over SSE (/api/sessions/{id}/messages).
+func (h *SessionHandler) Messages(w http.ResponseWriter, r *http.Request) {
+	sess, ok := h.Sessions.Get(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(sess.Messages)
+	case http.MethodPost:
+		h.askFollowUp(w, r, sess)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *SessionHandler) askFollowUp(w http.ResponseWriter, r *http.Request, sess models.Session) {
+	var req struct {
+		Question string `json:"question"`
+		// ConnectionID overrides the connection the session was analyzed with.
+		ConnectionID string `json:"connection_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Question) == "" {
+		http.Error(w, "Question is required", http.StatusBadRequest)
+		return
+	}
+
+	connectionID := req.ConnectionID
+	if connectionID == "" {
+		connectionID = sess.ConnectionID
+	}
+	engine, status, err := resolveEngine(h.Engine, h.Connections, connectionID)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	history := make([]llm.Message, 0, len(sess.Messages))
+	for _, m := range sess.Messages {
+		history = append(history, llm.Message{Role: llm.Role(m.Role), Content: m.Content})
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	response, err := engine.AnswerFollowUp(r.Context(), sess.Analysis, history, req.Question, func(token string) {
+		writeSSE(w, "token", token)
+		w.(http.Flusher).Flush()
+	})
+	if err != nil {
+		writeSSE(w, "error", err.Error())
+		return
+	}
+
+	err = h.Sessions.Append(sess.ID,
+		models.SessionMessage{Role: string(llm.RoleUser), Content: req.Question},
+		models.SessionMessage{Role: string(llm.RoleAssistant), Content: response.Content, Prompt: response.Prompt},
+	)
+	if err != nil {
+		fmt.Fprintf(w, "event: error\ndata: Failed to save session: %s\n\n", err.Error())
+		return
+	}
+
+	h.Usage.Record(engine.ConnectionID(), "followup", response.Usage)
+	writeProvider(w, response)
+	usageJSON, _ := json.Marshal(response.Usage)
+	fmt.Fprintf(w, "event: usage\ndata: %s\n\n", usageJSON)
+	fmt.Fprintf(w, "event: done\ndata: [DONE]\n\n")
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gigikoneti/tracemind/internal/analyzer"
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+type SimilarHandler struct {
+	// Pipeline must have a Similar index.
+	Pipeline *analyzer.Pipeline
+}
+
+// Similar returns past analyses of traces similar to the posted one.
+// ?k= and ?min_score= override the pipeline's retrieval settings.
+func (h *SimilarHandler) Similar(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	k, minScore := h.Pipeline.SimilarTopK, h.Pipeline.SimilarMinScore
+	if v := r.URL.Query().Get("k"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid k", http.StatusBadRequest)
+			return
+		}
+		k = n
+	}
+	if v := r.URL.Query().Get("min_score"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			http.Error(w, "Invalid min_score", http.StatusBadRequest)
+			return
+		}
+		minScore = f
+	}
+
+	var trace models.Trace
+	if err := json.NewDecoder(r.Body).Decode(&trace); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Gather facts without the pipeline's own retrieval, which would use
+	// the default k.
+	pipeline := *h.Pipeline
+	pipeline.Similar = nil
+	analysis := pipeline.Analyze(r.Context(), trace)
+
+	matches, err := h.Pipeline.Similar.Search(r.Context(), analysis, k, minScore)
+	if err != nil {
+		http.Error(w, "Similarity search failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	if matches == nil {
+		matches = []models.SimilarIncident{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"trace_id": trace.TraceID,
+		"matches":  matches,
+	})
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gigikoneti/tracemind/internal/llm"
+)
+
+type UsageHandler struct {
+	Tracker *llm.UsageTracker
+}
+
+// Usage reports token usage and estimated cost per connection and endpoint.
+func (h *UsageHandler) Usage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.Tracker.Report())
+}
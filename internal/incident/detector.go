@@ -0,0 +1,262 @@
+package incident
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gigikoneti/tracemind/internal/analyzer"
+	"github.com/gigikoneti/tracemind/internal/llm"
+	"github.com/gigikoneti/tracemind/internal/memory"
+	"github.com/gigikoneti/tracemind/internal/models"
+	"github.com/gigikoneti/tracemind/internal/notify"
+)
+
+const (
+	TriggerErrorRate      = "error_rate"
+	TriggerServiceLatency = "service_latency"
+)
+
+// Config holds the thresholds and pacing of the background detector.
+type Config struct {
+	ErrorRateThreshold float64
+	LatencyThresholdMs float64
+	Interval           time.Duration
+	// MinSpans avoids opening error-rate incidents from a handful of spans.
+	MinSpans     int
+	SampleTraces int
+	// ExplainTimeout bounds the LLM explanation of a single incident.
+	ExplainTimeout time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		ErrorRateThreshold: 0.2,
+		LatencyThresholdMs: 1000,
+		Interval:           30 * time.Second,
+		MinSpans:           20,
+		SampleTraces:       3,
+		ExplainTimeout:     5 * time.Minute,
+	}
+}
+
+// withDefaults replaces non-positive durations, which time.NewTicker and
+// context.WithTimeout cannot use, with the defaults.
+func (c Config) withDefaults() Config {
+	def := DefaultConfig()
+	if c.Interval <= 0 {
+		log.Printf("Ignoring non-positive incident check interval %s; using %s", c.Interval, def.Interval)
+		c.Interval = def.Interval
+	}
+	if c.ExplainTimeout <= 0 {
+		c.ExplainTimeout = def.ExplainTimeout
+	}
+	return c
+}
+
+// Detector watches memory aggregates and opens incidents on threshold breaches.
+type Detector struct {
+	Memory   *memory.Store
+	Pipeline *analyzer.Pipeline
+	Engine   *llm.Engine
+	Store    *Store
+	Config   Config
+	// Notifier is optional; when set it is told about every opened incident.
+	Notifier *notify.Notifier
+	Usage    *llm.UsageTracker
+}
+
+type breach struct {
+	trigger   string
+	service   string
+	observed  float64
+	threshold float64
+}
+
+// Run evaluates thresholds on every tick until ctx is cancelled.
+func (d *Detector) Run(ctx context.Context) {
+	d.Config = d.Config.withDefaults()
+	ticker := time.NewTicker(d.Config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.Check(ctx)
+		}
+	}
+}
+
+// Check runs a single detection pass: opening incidents for new breaches and
+// resolving open incidents whose condition has cleared.
+func (d *Detector) Check(ctx context.Context) {
+	breaches := d.findBreaches()
+
+	active := make(map[string]bool)
+	for _, b := range breaches {
+		active[b.trigger+"/"+b.service] = true
+		if _, exists := d.Store.FindOpen(b.trigger, b.service); exists {
+			continue
+		}
+		d.open(ctx, b)
+	}
+
+	for _, inc := range d.Store.List(models.IncidentOpen) {
+		if !active[inc.Trigger+"/"+inc.Service] {
+			d.Store.Resolve(inc.ID)
+			log.Printf("Incident %s resolved (%s)", inc.ID, inc.Description)
+		}
+	}
+}
+
+func (d *Detector) findBreaches() []breach {
+	var breaches []breach
+
+	stats := d.Memory.GetServiceStats()
+	var totalSpans int
+	for _, st := range stats {
+		totalSpans += st.Spans
+	}
+
+	health := d.Memory.GetHealth()
+	if totalSpans >= d.Config.MinSpans && d.Config.ErrorRateThreshold > 0 && health.RecentErrorRate >= d.Config.ErrorRateThreshold {
+		breaches = append(breaches, breach{
+			trigger:   TriggerErrorRate,
+			observed:  health.RecentErrorRate,
+			threshold: d.Config.ErrorRateThreshold,
+		})
+	}
+
+	if d.Config.LatencyThresholdMs > 0 {
+		for svc, st := range stats {
+			if st.AvgLatencyMs >= d.Config.LatencyThresholdMs {
+				breaches = append(breaches, breach{
+					trigger:   TriggerServiceLatency,
+					service:   svc,
+					observed:  st.AvgLatencyMs,
+					threshold: d.Config.LatencyThresholdMs,
+				})
+			}
+		}
+	}
+
+	return breaches
+}
+
+func (d *Detector) open(ctx context.Context, b breach) {
+	samples := d.representativeTraces(b)
+
+	inc := models.Incident{
+		Trigger:       b.trigger,
+		Service:       b.service,
+		Severity:      "critical",
+		ObservedValue: b.observed,
+		Threshold:     b.threshold,
+		Facts:         collectFacts(samples),
+	}
+	switch b.trigger {
+	case TriggerErrorRate:
+		inc.Description = fmt.Sprintf("Error rate %.2f%% exceeds threshold of %.2f%%.", b.observed*100, b.threshold*100)
+	case TriggerServiceLatency:
+		inc.Severity = "warning"
+		inc.Description = fmt.Sprintf("Service '%s' average latency %.2fms exceeds threshold of %.2fms.", b.service, b.observed, b.threshold)
+	}
+	for _, t := range samples {
+		inc.TraceIDs = append(inc.TraceIDs, t.TraceID)
+	}
+
+	inc = d.Store.Open(inc)
+	log.Printf("Incident %s opened: %s", inc.ID, inc.Description)
+	if d.Notifier != nil {
+		d.Notifier.NotifyAsync(notify.FromIncident(inc))
+	}
+
+	if d.Engine == nil || len(samples) == 0 {
+		return
+	}
+	go d.explain(ctx, inc, samples)
+}
+
+// representativeTraces picks the traces that best illustrate a breach: the most
+// error-heavy traces for error-rate incidents, the slowest occurrences of the
+// service for latency incidents.
+func (d *Detector) representativeTraces(b breach) []models.Trace {
+	type scored struct {
+		trace models.Trace
+		score float64
+	}
+
+	var candidates []scored
+	for _, t := range d.Memory.RecentTraces() {
+		var score float64
+		for _, span := range t.Spans {
+			switch b.trigger {
+			case TriggerErrorRate:
+				if span.Status.Code == "ERROR" {
+					score++
+				}
+			case TriggerServiceLatency:
+				if span.Name == b.service && span.LatencyMs() > score {
+					score = span.LatencyMs()
+				}
+			}
+		}
+		if score > 0 {
+			candidates = append(candidates, scored{trace: t, score: score})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	var samples []models.Trace
+	for i := 0; i < len(candidates) && i < d.Config.SampleTraces; i++ {
+		samples = append(samples, candidates[i].trace)
+	}
+	return samples
+}
+
+func (d *Detector) explain(ctx context.Context, inc models.Incident, samples []models.Trace) {
+	ctx, cancel := context.WithTimeout(ctx, d.Config.ExplainTimeout)
+	defer cancel()
+
+	var summary strings.Builder
+	var prompt *models.PromptRef
+	for _, t := range samples {
+		analysis := d.Pipeline.Analyze(ctx, t)
+
+		resp, err := d.Engine.ExplainTraceStream(ctx, analysis, true, func(string) {})
+		if err != nil {
+			log.Printf("Incident %s: explanation of trace %s failed: %v", inc.ID, t.TraceID, err)
+			continue
+		}
+		d.Usage.Record(d.Engine.ConnectionID(), "incident", resp.Usage)
+		prompt = resp.Prompt
+
+		summary.WriteString(fmt.Sprintf("### Trace %s\n%s\n\n", t.TraceID, strings.TrimSpace(resp.Content)))
+	}
+
+	d.Store.SetSummary(inc.ID, strings.TrimSpace(summary.String()), prompt)
+}
+
+func collectFacts(traces []models.Trace) []models.SymbolicFact {
+	var facts []models.SymbolicFact
+	seen := make(map[string]bool)
+	for _, t := range traces {
+		for _, f := range analyzer.AnalyzeTrace(t) {
+			key := f.Type + "/" + f.Service
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			facts = append(facts, f)
+		}
+	}
+	return facts
+}
@@ -0,0 +1,109 @@
+package incident
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+	"github.com/google/uuid"
+)
+
+// Store keeps detected incidents in memory.
+type Store struct {
+	mu        sync.RWMutex
+	incidents map[string]*models.Incident
+}
+
+func NewStore() *Store {
+	return &Store{
+		incidents: make(map[string]*models.Incident),
+	}
+}
+
+// Open records a new incident and returns a copy with its ID assigned.
+func (s *Store) Open(inc models.Incident) models.Incident {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inc.ID = uuid.New().String()
+	inc.Status = models.IncidentOpen
+	if inc.OpenedAt.IsZero() {
+		inc.OpenedAt = time.Now()
+	}
+	s.incidents[inc.ID] = &inc
+	return inc
+}
+
+// FindOpen returns the open incident for a trigger/service pair, if any.
+func (s *Store) FindOpen(trigger, service string) (models.Incident, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, inc := range s.incidents {
+		if inc.Status == models.IncidentOpen && inc.Trigger == trigger && inc.Service == service {
+			return *inc, true
+		}
+	}
+	return models.Incident{}, false
+}
+
+func (s *Store) Get(id string) (models.Incident, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	inc, ok := s.incidents[id]
+	if !ok {
+		return models.Incident{}, false
+	}
+	return *inc, true
+}
+
+// List returns incidents newest first, optionally filtered by status.
+func (s *Store) List(status models.IncidentStatus) []models.Incident {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	incidents := make([]models.Incident, 0, len(s.incidents))
+	for _, inc := range s.incidents {
+		if status != "" && inc.Status != status {
+			continue
+		}
+		incidents = append(incidents, *inc)
+	}
+
+	sort.Slice(incidents, func(i, j int) bool {
+		return incidents[i].OpenedAt.After(incidents[j].OpenedAt)
+	})
+	return incidents
+}
+
+// SetSummary stores the LLM explanation produced for an incident and the
+// prompt template version it came from.
+func (s *Store) SetSummary(id, summary string, prompt *models.PromptRef) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inc, ok := s.incidents[id]
+	if !ok {
+		return false
+	}
+	inc.Summary = summary
+	inc.Prompt = prompt
+	return true
+}
+
+// Resolve marks an open incident as resolved.
+func (s *Store) Resolve(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inc, ok := s.incidents[id]
+	if !ok || inc.Status == models.IncidentResolved {
+		return false
+	}
+	now := time.Now()
+	inc.Status = models.IncidentResolved
+	inc.ResolvedAt = &now
+	return true
+}
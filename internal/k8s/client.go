@@ -0,0 +1,145 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client is a minimal read-only Kubernetes API client covering the
+// resources TraceMind enriches traces with.
+type Client struct {
+	BaseURL string
+	Token   string
+	HTTP    *http.Client
+}
+
+type ObjectMeta struct {
+	Name            string           `json:"name"`
+	Namespace       string           `json:"namespace"`
+	Generation      int64            `json:"generation"`
+	OwnerReferences []OwnerReference `json:"ownerReferences"`
+}
+
+type OwnerReference struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+type Pod struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Status   struct {
+		Phase             string            `json:"phase"`
+		ContainerStatuses []ContainerStatus `json:"containerStatuses"`
+	} `json:"status"`
+}
+
+type ContainerStatus struct {
+	Name         string         `json:"name"`
+	RestartCount int            `json:"restartCount"`
+	Ready        bool           `json:"ready"`
+	State        ContainerState `json:"state"`
+	LastState    ContainerState `json:"lastState"`
+}
+
+type ContainerState struct {
+	Waiting *struct {
+		Reason string `json:"reason"`
+	} `json:"waiting,omitempty"`
+	Terminated *struct {
+		Reason     string    `json:"reason"`
+		ExitCode   int       `json:"exitCode"`
+		FinishedAt time.Time `json:"finishedAt"`
+	} `json:"terminated,omitempty"`
+}
+
+type Deployment struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     struct {
+		Replicas *int32 `json:"replicas"`
+	} `json:"spec"`
+	Status struct {
+		ObservedGeneration  int64 `json:"observedGeneration"`
+		Replicas            int32 `json:"replicas"`
+		UpdatedReplicas     int32 `json:"updatedReplicas"`
+		AvailableReplicas   int32 `json:"availableReplicas"`
+		UnavailableReplicas int32 `json:"unavailableReplicas"`
+	} `json:"status"`
+}
+
+type Event struct {
+	Reason         string    `json:"reason"`
+	Message        string    `json:"message"`
+	Type           string    `json:"type"`
+	Count          int       `json:"count"`
+	LastTimestamp  time.Time `json:"lastTimestamp"`
+	InvolvedObject struct {
+		Kind string `json:"kind"`
+		Name string `json:"name"`
+	} `json:"involvedObject"`
+}
+
+func (c *Client) GetPod(ctx context.Context, namespace, name string) (*Pod, error) {
+	var pod Pod
+	path := fmt.Sprintf("/api/v1/namespaces/%s/pods/%s", url.PathEscape(namespace), url.PathEscape(name))
+	if err := c.get(ctx, path, &pod); err != nil {
+		return nil, err
+	}
+	return &pod, nil
+}
+
+func (c *Client) GetDeployment(ctx context.Context, namespace, name string) (*Deployment, error) {
+	var deployment Deployment
+	path := fmt.Sprintf("/apis/apps/v1/namespaces/%s/deployments/%s", url.PathEscape(namespace), url.PathEscape(name))
+	if err := c.get(ctx, path, &deployment); err != nil {
+		return nil, err
+	}
+	return &deployment, nil
+}
+
+// ListEvents returns the events recorded for the named object.
+func (c *Client) ListEvents(ctx context.Context, namespace, objectName string) ([]Event, error) {
+	var list struct {
+		Items []Event `json:"items"`
+	}
+	query := url.Values{"fieldSelector": {"involvedObject.name=" + objectName}}
+	path := fmt.Sprintf("/api/v1/namespaces/%s/events?%s", url.PathEscape(namespace), query.Encode())
+	if err := c.get(ctx, path, &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	httpClient := c.HTTP
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("kubernetes request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("kubernetes API returned %d for %s: %s", resp.StatusCode, path, strings.TrimSpace(string(msg)))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
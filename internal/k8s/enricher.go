@@ -0,0 +1,224 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+// Enricher attaches Kubernetes workload state to traces as symbolic facts.
+type Enricher struct {
+	Client *Client
+	// RecentWindow is how close to the trace a restart or event must be to
+	// count as relevant.
+	RecentWindow time.Duration
+	Timeout      time.Duration
+}
+
+type workload struct {
+	service    string
+	namespace  string
+	pod        string
+	deployment string
+}
+
+// Enrich looks up the pods and deployments named in span attributes and
+// returns POD_RESTART, OOM_KILLED and ROLLOUT_IN_PROGRESS facts. Lookup
+// failures are logged and skipped so enrichment never blocks analysis.
+func (e *Enricher) Enrich(ctx context.Context, trace models.Trace) []models.SymbolicFact {
+	var facts []models.SymbolicFact
+
+	workloads := workloadsFromTrace(trace)
+	if len(workloads) == 0 {
+		return facts
+	}
+
+	if e.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.Timeout)
+		defer cancel()
+	}
+
+	traceStart, _ := trace.TimeWindow()
+	recentSince := traceStart.Add(-e.RecentWindow)
+
+	seenDeployments := make(map[string]bool)
+	for _, w := range workloads {
+		if w.pod != "" {
+			pod, err := e.Client.GetPod(ctx, w.namespace, w.pod)
+			if err != nil {
+				log.Printf("k8s enrichment: %v", err)
+			} else {
+				events, err := e.Client.ListEvents(ctx, w.namespace, w.pod)
+				if err != nil {
+					log.Printf("k8s enrichment: %v", err)
+				}
+				facts = append(facts, podFacts(w, pod, events, recentSince)...)
+
+				if w.deployment == "" {
+					w.deployment = deploymentFromOwner(pod.Metadata.OwnerReferences)
+				}
+			}
+		}
+
+		if w.deployment == "" || seenDeployments[w.namespace+"/"+w.deployment] {
+			continue
+		}
+		seenDeployments[w.namespace+"/"+w.deployment] = true
+
+		deployment, err := e.Client.GetDeployment(ctx, w.namespace, w.deployment)
+		if err != nil {
+			log.Printf("k8s enrichment: %v", err)
+			continue
+		}
+		if fact, ok := rolloutFact(w, deployment); ok {
+			facts = append(facts, fact)
+		}
+	}
+
+	return facts
+}
+
+func workloadsFromTrace(trace models.Trace) []workload {
+	seen := make(map[string]bool)
+	var workloads []workload
+
+	for i := range trace.Spans {
+		span := &trace.Spans[i]
+		w := workload{
+			service:    span.Name,
+			namespace:  stringAttr(span, "k8s.namespace.name"),
+			pod:        stringAttr(span, "k8s.pod.name"),
+			deployment: stringAttr(span, "k8s.deployment.name"),
+		}
+		if w.pod == "" && w.deployment == "" {
+			continue
+		}
+		if w.namespace == "" {
+			w.namespace = "default"
+		}
+
+		key := w.namespace + "/" + w.pod + "/" + w.deployment
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		workloads = append(workloads, w)
+	}
+
+	return workloads
+}
+
+func podFacts(w workload, pod *Pod, events []Event, recentSince time.Time) []models.SymbolicFact {
+	var facts []models.SymbolicFact
+
+	oomKilled := false
+	for _, cs := range pod.Status.ContainerStatuses {
+		for _, state := range []ContainerState{cs.State, cs.LastState} {
+			if t := state.Terminated; t != nil && t.Reason == "OOMKilled" && !t.FinishedAt.Before(recentSince) {
+				oomKilled = true
+			}
+		}
+	}
+
+	var warnings []string
+	for _, ev := range events {
+		if ev.LastTimestamp.Before(recentSince) {
+			continue
+		}
+		if ev.Reason == "OOMKilling" {
+			oomKilled = true
+		}
+		if ev.Type == "Warning" {
+			warnings = append(warnings, fmt.Sprintf("%s: %s", ev.Reason, ev.Message))
+		}
+	}
+	sort.Strings(warnings)
+
+	if oomKilled {
+		facts = append(facts, models.SymbolicFact{
+			Type:        "OOM_KILLED",
+			Service:     w.service,
+			Description: fmt.Sprintf("Pod '%s/%s' serving '%s' was OOMKilled.", w.namespace, w.pod, w.service),
+			Severity:    "critical",
+		})
+	}
+
+	// Restarts count only when the last one ended within the recent
+	// window; an old restart count says nothing about this trace.
+	for _, cs := range pod.Status.ContainerStatuses {
+		t := cs.LastState.Terminated
+		if cs.RestartCount == 0 || t == nil || t.FinishedAt.Before(recentSince) {
+			continue
+		}
+
+		desc := fmt.Sprintf("Container '%s' of pod '%s/%s' serving '%s' has restarted %d times", cs.Name, w.namespace, w.pod, w.service, cs.RestartCount)
+		desc += fmt.Sprintf("; last termination: %s (exit %d) at %s", t.Reason, t.ExitCode, t.FinishedAt.Format(time.RFC3339))
+		if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" {
+			desc += fmt.Sprintf("; currently %s", cs.State.Waiting.Reason)
+		}
+		if len(warnings) > 0 {
+			desc += fmt.Sprintf("; recent events: %s", strings.Join(warnings, "; "))
+		}
+
+		facts = append(facts, models.SymbolicFact{
+			Type:        "POD_RESTART",
+			Service:     w.service,
+			Description: desc + ".",
+			Severity:    "critical",
+		})
+	}
+
+	return facts
+}
+
+func rolloutFact(w workload, d *Deployment) (models.SymbolicFact, bool) {
+	desired := int32(1)
+	if d.Spec.Replicas != nil {
+		desired = *d.Spec.Replicas
+	}
+
+	inProgress := d.Status.ObservedGeneration < d.Metadata.Generation ||
+		d.Status.UpdatedReplicas < desired ||
+		d.Status.Replicas > d.Status.UpdatedReplicas ||
+		d.Status.UnavailableReplicas > 0
+	if !inProgress {
+		return models.SymbolicFact{}, false
+	}
+
+	return models.SymbolicFact{
+		Type:    "ROLLOUT_IN_PROGRESS",
+		Service: w.service,
+		Description: fmt.Sprintf("Deployment '%s/%s' serving '%s' is rolling out: %d/%d replicas updated, %d available, %d unavailable.",
+			w.namespace, d.Metadata.Name, w.service, d.Status.UpdatedReplicas, desired, d.Status.AvailableReplicas, d.Status.UnavailableReplicas),
+		Severity: "warning",
+	}, true
+}
+
+// deploymentFromOwner derives the deployment name from a pod's ReplicaSet
+// owner, which Kubernetes names "<deployment>-<pod-template-hash>".
+func deploymentFromOwner(owners []OwnerReference) string {
+	for _, o := range owners {
+		if o.Kind != "ReplicaSet" {
+			continue
+		}
+		if i := strings.LastIndex(o.Name, "-"); i > 0 {
+			return o.Name[:i]
+		}
+	}
+	return ""
+}
+
+func stringAttr(span *models.Span, key string) string {
+	v, ok := span.Attribute(key)
+	if !ok {
+		return ""
+	}
+	s, _ := v.(string)
+	return s
+}
@@ -0,0 +1,147 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+// fakeAPIServer serves fixed pods, deployments and events the way the
+// Kubernetes API does.
+func fakeAPIServer(t *testing.T, objects map[string]interface{}) *Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		obj, ok := objects[r.URL.Path]
+		if !ok {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(obj)
+	}))
+	t.Cleanup(srv.Close)
+	return &Client{BaseURL: srv.URL, Token: "test-token"}
+}
+
+func terminated(reason string, finishedAt time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"terminated": map[string]interface{}{"reason": reason, "exitCode": 137, "finishedAt": finishedAt},
+	}
+}
+
+func podObject(name string, restarts int, state, lastState map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":            name,
+			"namespace":       "shop",
+			"ownerReferences": []map[string]interface{}{{"kind": "ReplicaSet", "name": "payment-7d9f8c"}},
+		},
+		"status": map[string]interface{}{
+			"phase": "Running",
+			"containerStatuses": []map[string]interface{}{{
+				"name":         "app",
+				"restartCount": restarts,
+				"state":        state,
+				"lastState":    lastState,
+			}},
+		},
+	}
+}
+
+func enrichTrace(t *testing.T, objects map[string]interface{}, traceStart time.Time) []models.SymbolicFact {
+	objects["/api/v1/namespaces/shop/events"] = map[string]interface{}{"items": []interface{}{}}
+	objects["/apis/apps/v1/namespaces/shop/deployments/payment"] = map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "payment", "generation": 1},
+		"spec":     map[string]interface{}{"replicas": 1},
+		"status":   map[string]interface{}{"observedGeneration": 1, "replicas": 1, "updatedReplicas": 1, "availableReplicas": 1},
+	}
+
+	e := &Enricher{Client: fakeAPIServer(t, objects), RecentWindow: 15 * time.Minute, Timeout: 5 * time.Second}
+	trace := models.Trace{TraceID: "t1", Spans: []models.Span{{
+		SpanID:    "s1",
+		Name:      "payment-service",
+		StartTime: traceStart,
+		EndTime:   traceStart.Add(100 * time.Millisecond),
+		Attributes: []models.Attribute{
+			{Key: "k8s.namespace.name", Value: "shop"},
+			{Key: "k8s.pod.name", Value: "payment-7d9f8c-abcde"},
+		},
+	}}}
+	return e.Enrich(context.Background(), trace)
+}
+
+func factTypes(facts []models.SymbolicFact) []string {
+	var types []string
+	for _, f := range facts {
+		types = append(types, f.Type)
+	}
+	sort.Strings(types)
+	return types
+}
+
+func TestEnrichReportsRecentOOMKill(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	facts := enrichTrace(t, map[string]interface{}{
+		"/api/v1/namespaces/shop/pods/payment-7d9f8c-abcde": podObject("payment-7d9f8c-abcde", 3,
+			map[string]interface{}{"running": map[string]interface{}{}},
+			terminated("OOMKilled", start.Add(-2*time.Minute))),
+	}, start)
+
+	got := factTypes(facts)
+	if len(got) != 2 || got[0] != "OOM_KILLED" || got[1] != "POD_RESTART" {
+		t.Fatalf("facts = %v, want OOM_KILLED and POD_RESTART", got)
+	}
+	for _, f := range facts {
+		if f.Service != "payment-service" || f.Severity != "critical" {
+			t.Errorf("fact %s: service %q severity %q", f.Type, f.Service, f.Severity)
+		}
+	}
+}
+
+func TestEnrichIgnoresOldTerminations(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	facts := enrichTrace(t, map[string]interface{}{
+		"/api/v1/namespaces/shop/pods/payment-7d9f8c-abcde": podObject("payment-7d9f8c-abcde", 1,
+			map[string]interface{}{"running": map[string]interface{}{}},
+			terminated("OOMKilled", start.Add(-3*24*time.Hour))),
+	}, start)
+
+	if len(facts) != 0 {
+		t.Fatalf("facts = %v, want none for a restart days before the trace", factTypes(facts))
+	}
+}
+
+func TestEnrichReportsRollout(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	objects := map[string]interface{}{
+		"/api/v1/namespaces/shop/pods/payment-7d9f8c-abcde": podObject("payment-7d9f8c-abcde", 0, nil, nil),
+	}
+	facts := enrichTrace(t, objects, start)
+	if len(facts) != 0 {
+		t.Fatalf("facts = %v, want none for a healthy pod", factTypes(facts))
+	}
+
+	objects["/apis/apps/v1/namespaces/shop/deployments/payment"] = map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "payment", "generation": 2},
+		"spec":     map[string]interface{}{"replicas": 3},
+		"status":   map[string]interface{}{"observedGeneration": 2, "replicas": 4, "updatedReplicas": 1, "availableReplicas": 3, "unavailableReplicas": 1},
+	}
+	e := &Enricher{Client: fakeAPIServer(t, objects), RecentWindow: 15 * time.Minute}
+	facts = e.Enrich(context.Background(), models.Trace{Spans: []models.Span{{
+		Name:       "payment-service",
+		StartTime:  start,
+		EndTime:    start,
+		Attributes: []models.Attribute{{Key: "k8s.namespace.name", Value: "shop"}, {Key: "k8s.deployment.name", Value: "payment"}},
+	}}})
+	if got := factTypes(facts); len(got) != 1 || got[0] != "ROLLOUT_IN_PROGRESS" {
+		t.Fatalf("facts = %v, want ROLLOUT_IN_PROGRESS", got)
+	}
+}
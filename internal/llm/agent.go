@@ -0,0 +1,190 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DefaultAgentSteps bounds how many rounds of tool calls an agent may make.
+const DefaultAgentSteps = 8
+
+// Tool is a function the model may call. Parameters is a JSON Schema object.
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  map[string]any
+}
+
+type ToolCall struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// ToolExecutor runs a tool call and returns its result, usually JSON.
+type ToolExecutor func(ctx context.Context, call ToolCall) (string, error)
+
+const (
+	AgentEventThought    = "thought"
+	AgentEventToolCall   = "tool_call"
+	AgentEventToolResult = "tool_result"
+)
+
+// AgentEvent reports one step of an agent run as it happens.
+type AgentEvent struct {
+	Type    string    `json:"type"`
+	Step    int       `json:"step"`
+	Content string    `json:"content,omitempty"`
+	Call    *ToolCall `json:"call,omitempty"`
+	Result  string    `json:"result,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// nativeToolCaller is implemented by providers that support req.Tools.
+type nativeToolCaller interface {
+	supportsNativeTools() bool
+}
+
+const stepLimitPrompt = "You have reached the tool call limit. Give your final answer now using what you have found, without calling any more tools."
+
+// RunAgent lets the model call tools until it answers without one or
+// maxSteps rounds have passed, then returns the final answer. Providers
+// without native function calling are driven through a JSON protocol
+// described in the system prompt.
+func (e *Engine) RunAgent(ctx context.Context, req GenerateRequest, tools []Tool, execute ToolExecutor, maxSteps int, onEvent func(AgentEvent)) (*GenerateResponse, error) {
+	if maxSteps <= 0 {
+		maxSteps = DefaultAgentSteps
+	}
+	if p, ok := e.provider.(nativeToolCaller); ok && p.supportsNativeTools() {
+		return e.runNativeAgent(ctx, req, tools, execute, maxSteps, onEvent)
+	}
+	return e.runJSONAgent(ctx, req, tools, execute, maxSteps, onEvent)
+}
+
+func (e *Engine) runNativeAgent(ctx context.Context, req GenerateRequest, tools []Tool, execute ToolExecutor, maxSteps int, onEvent func(AgentEvent)) (*GenerateResponse, error) {
+	req.Tools = tools
+
+	var usage Usage
+	for step := 1; ; step++ {
+		resp, err := e.GenerateText(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		usage = addUsage(usage, resp.Usage)
+
+		if len(resp.ToolCalls) == 0 || step > maxSteps {
+			if resp.Content == "" {
+				return nil, fmt.Errorf("agent gave no answer within %d steps", maxSteps)
+			}
+			resp.ToolCalls = nil
+			resp.Usage = usage
+			return resp, nil
+		}
+
+		if resp.Content != "" {
+			onEvent(AgentEvent{Type: AgentEventThought, Step: step, Content: resp.Content})
+		}
+		for i := range resp.ToolCalls {
+			if len(resp.ToolCalls[i].Arguments) == 0 {
+				resp.ToolCalls[i].Arguments = json.RawMessage("{}")
+			}
+		}
+		req.Messages = append(req.Messages, Message{Role: RoleAssistant, Content: resp.Content, ToolCalls: resp.ToolCalls})
+		for _, call := range resp.ToolCalls {
+			req.Messages = append(req.Messages, Message{
+				Role:       RoleTool,
+				Content:    runTool(ctx, step, call, execute, onEvent),
+				ToolCallID: call.ID,
+			})
+		}
+		if step == maxSteps {
+			req.Messages = append(req.Messages, Message{Role: RoleUser, Content: stepLimitPrompt})
+			req.DisableTools = true
+		}
+	}
+}
+
+// agentReply is the JSON protocol reply: either a tool call or a final answer.
+type agentReply struct {
+	Thought     string          `json:"thought"`
+	Tool        string          `json:"tool"`
+	Arguments   json.RawMessage `json:"arguments"`
+	FinalAnswer string          `json:"final_answer"`
+}
+
+func (e *Engine) runJSONAgent(ctx context.Context, req GenerateRequest, tools []Tool, execute ToolExecutor, maxSteps int, onEvent func(AgentEvent)) (*GenerateResponse, error) {
+	req.System = strings.TrimSpace(req.System + "\n\n" + jsonToolProtocol(tools))
+	req.ResponseFormat = ResponseFormatJSON
+
+	var usage Usage
+	for step := 1; ; step++ {
+		resp, err := e.GenerateText(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		usage = addUsage(usage, resp.Usage)
+		resp.Usage = usage
+
+		var reply agentReply
+		if err := json.Unmarshal([]byte(extractJSON(resp.Content)), &reply); err != nil || reply.Tool == "" || reply.FinalAnswer != "" {
+			// Anything that is not a tool call is taken as the answer.
+			if reply.FinalAnswer != "" {
+				resp.Content = reply.FinalAnswer
+			}
+			return resp, nil
+		}
+		if step > maxSteps {
+			return nil, fmt.Errorf("agent gave no answer within %d steps", maxSteps)
+		}
+
+		if reply.Thought != "" {
+			onEvent(AgentEvent{Type: AgentEventThought, Step: step, Content: reply.Thought})
+		}
+		call := ToolCall{ID: fmt.Sprintf("call_%d", step), Name: reply.Tool, Arguments: reply.Arguments}
+		if len(call.Arguments) == 0 {
+			call.Arguments = json.RawMessage("{}")
+		}
+		result := runTool(ctx, step, call, execute, onEvent)
+
+		next := fmt.Sprintf("Result of %s:\n%s", call.Name, result)
+		if step == maxSteps {
+			next += "\n\n" + stepLimitPrompt + ` Reply with {"final_answer": "..."}.`
+		}
+		req.Messages = append(req.Messages,
+			Message{Role: RoleAssistant, Content: resp.Content},
+			Message{Role: RoleUser, Content: next},
+		)
+	}
+}
+
+// runTool executes a call, reporting it and its result. Failures are
+// returned to the model as the result so it can recover.
+func runTool(ctx context.Context, step int, call ToolCall, execute ToolExecutor, onEvent func(AgentEvent)) string {
+	onEvent(AgentEvent{Type: AgentEventToolCall, Step: step, Call: &call})
+
+	result, err := execute(ctx, call)
+	if err != nil {
+		onEvent(AgentEvent{Type: AgentEventToolResult, Step: step, Call: &call, Error: err.Error()})
+		return "error: " + err.Error()
+	}
+	onEvent(AgentEvent{Type: AgentEventToolResult, Step: step, Call: &call, Result: result})
+	return result
+}
+
+func jsonToolProtocol(tools []Tool) string {
+	var sb strings.Builder
+	sb.WriteString("You can call these tools:\n")
+	for _, tool := range tools {
+		params, _ := json.Marshal(tool.Parameters)
+		sb.WriteString(fmt.Sprintf("- %s: %s Arguments schema: %s\n", tool.Name, tool.Description, params))
+	}
+	sb.WriteString(`
+Reply with exactly one JSON object per turn, either
+{"thought": "why you need it", "tool": "<tool name>", "arguments": {...}}
+to call a tool and receive its result, or
+{"final_answer": "<your complete answer>"}
+when you are done.`)
+	return sb.String()
+}
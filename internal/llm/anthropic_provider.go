@@ -3,6 +3,7 @@ package llm
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/anthropics/anthropic-sdk-go/option"
@@ -26,48 +27,152 @@ func NewAnthropicProvider(model, apiKey string) (*AnthropicProvider, error) {
 	}, nil
 }
 
-func (p *AnthropicProvider) Generate(ctx context.Context, prompt string) (string, error) {
-	message, err := p.client.Messages.New(ctx, anthropic.MessageNewParams{
-		Model:     p.model,
-		MaxTokens: 4096,
-		Messages: []anthropic.MessageParam{
-			anthropic.NewUserMessage(anthropic.NewTextBlock(prompt)),
-		},
-	})
+// structuredOutputModelPrefixes lists the model families that accept an
+// output_config JSON schema.
+var structuredOutputModelPrefixes = []string{"claude-sonnet-4-5", "claude-opus-4-1", "claude-opus-4-5", "claude-haiku-4-5"}
+
+func (p *AnthropicProvider) supportsStructuredOutput() bool {
+	for _, prefix := range structuredOutputModelPrefixes {
+		if strings.HasPrefix(string(p.model), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultMaxTokens is used when a request does not set MaxTokens, which Anthropic requires.
+const defaultMaxTokens = 4096
+
+func (p *AnthropicProvider) buildParams(req GenerateRequest) anthropic.MessageNewParams {
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = defaultMaxTokens
+	}
+
+	// Tool results travel as user content, so consecutive tool and user
+	// messages are merged into a single user turn.
+	messages := make([]anthropic.MessageParam, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		var blocks []anthropic.ContentBlockParamUnion
+		switch {
+		case m.Role == RoleTool:
+			blocks = append(blocks, anthropic.NewToolResultBlock(m.ToolCallID, m.Content, false))
+		case m.Content != "":
+			blocks = append(blocks, anthropic.NewTextBlock(m.Content))
+		}
+		for _, call := range m.ToolCalls {
+			blocks = append(blocks, anthropic.NewToolUseBlock(call.ID, call.Arguments, call.Name))
+		}
+
+		role := anthropic.MessageParamRoleUser
+		if m.Role == RoleAssistant {
+			role = anthropic.MessageParamRoleAssistant
+		}
+		if n := len(messages); n > 0 && messages[n-1].Role == role {
+			messages[n-1].Content = append(messages[n-1].Content, blocks...)
+			continue
+		}
+		messages = append(messages, anthropic.MessageParam{Role: role, Content: blocks})
+	}
+
+	params := anthropic.MessageNewParams{
+		Model:         p.model,
+		MaxTokens:     int64(maxTokens),
+		Messages:      messages,
+		StopSequences: req.StopSequences,
+	}
+	// Anthropic has no schema-less JSON mode and no seed parameter; schemas
+	// are enforced natively on models with structured outputs.
+	system := systemWithJSONHint(req)
+	if req.ResponseFormat == ResponseFormatJSON && req.JSONSchema != nil && p.supportsStructuredOutput() {
+		system = req.System
+		params.OutputConfig = anthropic.OutputConfigParam{
+			Format: anthropic.JSONOutputFormatParam{Schema: req.JSONSchema.Schema},
+		}
+	}
+	if system != "" {
+		params.System = []anthropic.TextBlockParam{{Text: system}}
+	}
+	for _, tool := range req.Tools {
+		schema := anthropic.ToolInputSchemaParam{Properties: tool.Parameters["properties"]}
+		if required, ok := tool.Parameters["required"].([]string); ok {
+			schema.Required = required
+		}
+		params.Tools = append(params.Tools, anthropic.ToolUnionParam{OfTool: &anthropic.ToolParam{
+			Name:        tool.Name,
+			Description: anthropic.String(tool.Description),
+			InputSchema: schema,
+		}})
+	}
+	if req.DisableTools && len(params.Tools) > 0 {
+		params.ToolChoice = anthropic.ToolChoiceUnionParam{OfNone: &anthropic.ToolChoiceNoneParam{}}
+	}
+	if req.Temperature != nil {
+		params.Temperature = anthropic.Float(*req.Temperature)
+	}
+	return params
+}
+
+func (p *AnthropicProvider) Generate(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
+	message, err := p.client.Messages.New(ctx, p.buildParams(req))
 
 	if err != nil {
-		return "", fmt.Errorf("anthropic generation failed: %w", err)
+		return nil, fmt.Errorf("anthropic generation failed: %w", err)
 	}
 
 	if len(message.Content) == 0 {
-		return "", fmt.Errorf("no response from anthropic")
+		return nil, fmt.Errorf("no response from anthropic")
 	}
 
-	return message.Content[0].Text, nil
+	result := &GenerateResponse{
+		Usage: newUsage(int(message.Usage.InputTokens), int(message.Usage.OutputTokens)),
+	}
+	var text strings.Builder
+	for _, block := range message.Content {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "tool_use":
+			result.ToolCalls = append(result.ToolCalls, ToolCall{
+				ID:        block.ID,
+				Name:      block.Name,
+				Arguments: block.Input,
+			})
+		}
+	}
+	result.Content = text.String()
+	return result, nil
 }
 
-func (p *AnthropicProvider) GenerateStream(ctx context.Context, prompt string, onToken func(string)) error {
-	stream := p.client.Messages.NewStreaming(ctx, anthropic.MessageNewParams{
-		Model:     p.model,
-		MaxTokens: 4096,
-		Messages: []anthropic.MessageParam{
-			anthropic.NewUserMessage(anthropic.NewTextBlock(prompt)),
-		},
-	})
+func (p *AnthropicProvider) supportsNativeTools() bool { return true }
 
+func (p *AnthropicProvider) GenerateStream(ctx context.Context, req GenerateRequest, onToken func(string)) (*GenerateResponse, error) {
+	stream := p.client.Messages.NewStreaming(ctx, p.buildParams(req))
+
+	// Accumulating the events rebuilds the final message, including usage
+	// from message_start and message_delta.
+	var message anthropic.Message
+	var content strings.Builder
 	for stream.Next() {
 		event := stream.Current()
+		if err := message.Accumulate(event); err != nil {
+			return nil, fmt.Errorf("anthropic stream error: %w", err)
+		}
 
 		if event.Type == "content_block_delta" {
 			if event.Delta.Type == "text_delta" {
+				content.WriteString(event.Delta.Text)
 				onToken(event.Delta.Text)
 			}
 		}
 	}
 
 	if err := stream.Err(); err != nil {
-		return fmt.Errorf("anthropic stream error: %w", err)
+		return nil, fmt.Errorf("anthropic stream error: %w", err)
 	}
 
-	return nil
+	return &GenerateResponse{
+		Content: content.String(),
+		Usage:   newUsage(int(message.Usage.InputTokens), int(message.Usage.OutputTokens)),
+	}, nil
 }
@@ -0,0 +1,100 @@
+package llm
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+)
+
+// contextSizes is keyed by model name prefix; the longest matching prefix wins.
+var contextSizes = map[string]int{
+	"gpt-5":         400000,
+	"gpt-4.1":       1047576,
+	"gpt-4o":        128000,
+	"gpt-4-turbo":   128000,
+	"gpt-4-32k":     32768,
+	"gpt-4":         8192,
+	"gpt-3.5-turbo": 16385,
+	"o1":            200000,
+	"o1-mini":       128000,
+	"o1-preview":    128000,
+	"o3":            200000,
+	"o4":            200000,
+	"claude":        200000,
+}
+
+const (
+	// defaultOllamaContextSize matches Ollama's default num_ctx.
+	defaultOllamaContextSize = 4096
+	defaultContextSize       = 8192
+	// promptSafetyMargin absorbs tokenizer differences between providers,
+	// since counts use an OpenAI encoding.
+	promptSafetyMargin = 0.9
+)
+
+var (
+	encodingOnce sync.Once
+	encoding     *tiktoken.Tiktoken
+)
+
+// ContextSize returns the context window, in tokens, of a model.
+func ContextSize(provider AIProvider, model string) int {
+	if provider == ProviderOllama {
+		return defaultOllamaContextSize
+	}
+
+	var best string
+	for prefix := range contextSizes {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return defaultContextSize
+	}
+	return contextSizes[best]
+}
+
+// LoadTokenizer loads the cl100k_base encoding used by CountTokens, from
+// the tiktoken cache or by downloading it within tokenizerTimeout. Calling
+// it at startup keeps the download off the first request; CountTokens
+// loads it on first use otherwise.
+func LoadTokenizer() {
+	encodingOnce.Do(func() {
+		tiktoken.SetBpeLoader(&bpeLoader{client: &http.Client{Timeout: tokenizerTimeout}})
+		var err error
+		encoding, err = tiktoken.GetEncoding(tiktoken.MODEL_CL100K_BASE)
+		if err != nil {
+			log.Printf("Token counting falls back to an approximation: %v", err)
+		}
+	})
+}
+
+// CountTokens estimates the tokens in text with the cl100k_base encoding,
+// falling back to four characters per token when the encoding cannot be loaded.
+func CountTokens(text string) int {
+	LoadTokenizer()
+	if encoding == nil {
+		return (len([]rune(text)) + 3) / 4
+	}
+	return len(encoding.Encode(text, nil, nil))
+}
+
+// promptBudget is the number of prompt tokens a request may use on a model,
+// leaving room for the completion.
+func promptBudget(config ProviderConfig, req GenerateRequest) int {
+	size := ContextSize(config.Provider, config.Model)
+
+	reserved := req.MaxTokens
+	if reserved <= 0 {
+		reserved = size / 4
+		if reserved > defaultMaxTokens {
+			reserved = defaultMaxTokens
+		}
+	}
+
+	return int(float64(size-reserved) * promptSafetyMargin)
+}
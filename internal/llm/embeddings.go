@@ -0,0 +1,63 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Embedder turns texts into vectors for similarity search. Vectors from
+// different models are not comparable.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// NewEmbedder builds an embedder for an embedding model. Anthropic has no
+// embeddings API.
+func NewEmbedder(provider AIProvider, endpoint, model, apiKey string) (Embedder, error) {
+	switch provider {
+	case ProviderOpenAI:
+		return NewOpenAIProvider(endpoint, model, apiKey)
+	case ProviderOllama:
+		return NewOllamaProvider(endpoint, model)
+	default:
+		return nil, fmt.Errorf("embeddings are not supported for provider: %s", provider)
+	}
+}
+
+// Embed uses the provider's model, which must be an embedding model such as
+// text-embedding-3-small.
+func (p *OpenAIProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	resp, err := p.client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Input: texts,
+		Model: openai.EmbeddingModel(p.model),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("openai embedding failed: %w", err)
+	}
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("openai returned %d embeddings for %d texts", len(resp.Data), len(texts))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("openai returned embedding for unknown index %d", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
+
+// Embed uses the provider's model, e.g. nomic-embed-text.
+func (p *OllamaProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors, err := p.llm.CreateEmbedding(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("ollama embedding failed: %w", err)
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("ollama returned %d embeddings for %d texts", len(vectors), len(texts))
+	}
+	return vectors, nil
+}
@@ -2,33 +2,24 @@ package llm
 
 import (
 	"context"
-	"fmt"
-	"strings"
 
 	"github.com/gigikoneti/tracemind/internal/models"
-	"github.com/tmc/langchaingo/llms"
-	"github.com/tmc/langchaingo/llms/ollama"
 )
 
 type Engine struct {
-	llm      *ollama.LLM
-	provider LLMProvider
-	config   ProviderConfig
+	provider     LLMProvider
+	config       ProviderConfig
+	connectionID string
 }
 
+// NewEngine builds the default engine backed by the local Ollama server.
 func NewEngine(modelName string) (*Engine, error) {
-	l, err := ollama.New(ollama.WithModel(modelName))
-	if err != nil {
-		return nil, err
-	}
-
 	ollamaProvider, err := NewOllamaProvider("", modelName)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Engine{
-		llm:      l,
 		provider: ollamaProvider,
 		config: ProviderConfig{
 			Provider: ProviderOllama,
@@ -38,92 +29,64 @@ func NewEngine(modelName string) (*Engine, error) {
 }
 
 // ExplainTraceStream uses the LLM to provide a streaming causal explanation.
-func (e *Engine) ExplainTraceStream(ctx context.Context, trace models.Trace, facts []models.SymbolicFact, health models.SystemHealth, useStructured bool, onToken func(string)) error {
-	var prompt string
+func (e *Engine) ExplainTraceStream(ctx context.Context, analysis models.TraceAnalysis, useStructured bool, onToken func(string)) (*GenerateResponse, error) {
 	if useStructured {
-		prompt = buildStructuredPrompt(trace, facts, health)
-	} else {
-		prompt = buildRawPrompt(trace)
+		return e.Explain(ctx, analysis, ExplainOptions{}, onToken)
 	}
 
-	_, err := llms.GenerateFromSinglePrompt(ctx, e.llm, prompt,
-		llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
-			onToken(string(chunk))
-			return nil
-		}),
-	)
-	return err
+	// The unscaffolded baseline used by Research Mode: no system prompt,
+	// facts or memory.
+	req, ref, err := renderPrompt(PromptRawAnalysis, AnalysisPromptData{TraceAnalysis: analysis})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := e.GenerateTextStream(ctx, req, onToken)
+	if err != nil {
+		return nil, err
+	}
+	resp.Prompt = &ref
+	return resp, nil
 }
 
-// EvaluateExplanation uses LLM-as-a-Judge to score an explanation.
-func (e *Engine) EvaluateExplanation(ctx context.Context, trace models.Trace, facts []models.SymbolicFact, explanation string) (string, error) {
-	prompt := fmt.Sprintf(`
-You are a Senior SRE Auditor. Evaluate the following AI-generated incident explanation based on technical correctness and causal logic.
-
-Trace Data (Simplified):
-%v
-
-Computed Symbolic Facts:
-%v
-
-AI Explanation to Evaluate:
-"""
-%s
-"""
-
-Task:
-Score the explanation from 1-10 on 'Causal Correctness'.
-Explain why you gave that score.
-Check if the explanation identified the root cause mentioned in the symbolic facts.
-
-Response format:
-Score: [1-10]
-Rationale: [Brief explanation]
-Root Cause Found: [Yes/No]
-`, trace, facts, explanation)
-
-	completion, err := llms.GenerateFromSinglePrompt(ctx, e.llm, prompt)
-	return completion, err
+// ExplainOptions picks the prompt template and sampling parameters of an
+// explanation. Zero values use the analysis template and provider defaults.
+type ExplainOptions struct {
+	Template    string
+	Temperature *float64
+	MaxTokens   int
+	Seed        *int
+	// SpanIDs lists span IDs in the prompt so the answer can cite them.
+	SpanIDs bool
 }
 
-func buildRawPrompt(trace models.Trace) string {
-	var sb strings.Builder
-	sb.WriteString("Analyze this OTel trace and explain what happened:\n\n")
-	for _, s := range trace.Spans {
-		sb.WriteString(fmt.Sprintf("- %s: %s [%.2fms]\n", s.Name, s.Status.Code, s.LatencyMs()))
+// Explain streams an explanation rendered from any trace analysis template.
+func (e *Engine) Explain(ctx context.Context, analysis models.TraceAnalysis, opts ExplainOptions, onToken func(string)) (*GenerateResponse, error) {
+	name := opts.Template
+	if name == "" {
+		name = PromptAnalysis
 	}
-	return sb.String()
-}
 
-func buildStructuredPrompt(trace models.Trace, facts []models.SymbolicFact, health models.SystemHealth) string {
-	var sb strings.Builder
-	sb.WriteString("You are an expert SRE Agent. Analyze this OTel trace using both current telemetry and historical system context.\n\n")
-
-	sb.WriteString("### Global System Context (Symbolic Memory):\n")
-	sb.WriteString(fmt.Sprintf("- Overall Error Rate: %.2f%%\n", health.RecentErrorRate*100))
-	if len(health.SlowestServices) > 0 {
-		sb.WriteString(fmt.Sprintf("- Recent Latency Trends: Services %s have been slow recently.\n", strings.Join(health.SlowestServices, ", ")))
+	req, ref, err := buildContextPrompt(analysis, promptBudget(e.config, GenerateRequest{MaxTokens: opts.MaxTokens}), name, opts.SpanIDs)
+	if err != nil {
+		return nil, err
 	}
+	req.Temperature = opts.Temperature
+	req.MaxTokens = opts.MaxTokens
+	req.Seed = opts.Seed
 
-	sb.WriteString("\n### Symbolic Facts for This Trace:\n")
-	for _, f := range facts {
-		sb.WriteString(fmt.Sprintf("- [%s] %s: %s\n", f.Severity, f.Type, f.Description))
+	resp, err := e.GenerateTextStream(ctx, req, onToken)
+	if err != nil {
+		return nil, err
 	}
+	resp.Prompt = &ref
+	return resp, nil
+}
 
-	sb.WriteString("\n### OTel Spans:\n")
-	for _, s := range trace.Spans {
-		status := s.Status.Code
-		if s.Status.Message != "" {
-			status += fmt.Sprintf(" (%s)", s.Status.Message)
-		}
-		sb.WriteString(fmt.Sprintf("- %s: %s [%.2fms]\n", s.Name, status, s.LatencyMs()))
-	}
+const analystSystemPrompt = `You are an expert SRE Agent. Analyze OTel traces using both current telemetry and historical system context.
 
-	sb.WriteString("\n### Task:\n")
-	sb.WriteString("1. Determine if this is an isolated incident or part of a systemic trend based on the global context.\n")
-	sb.WriteString("2. Explain the root cause and propagation.\n")
-	sb.WriteString("3. Provide high-priority remediation steps.\n")
-	sb.WriteString("\nBe technical, concise, and definitive.")
+Task:
+1. Determine if this is an isolated incident or part of a systemic trend based on the global context.
+2. Explain the root cause and propagation. If a recent change plausibly caused it, name it (e.g. "likely caused by deploy v1.4.2").
+3. Provide high-priority remediation steps.
 
-	return sb.String()
-}
+Be technical, concise, and definitive.`
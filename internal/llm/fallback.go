@@ -0,0 +1,230 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	DefaultFallbackRetries = 2
+	DefaultFallbackBackoff = 500 * time.Millisecond
+	// maxFallbackBackoff caps the exponential backoff between retries.
+	maxFallbackBackoff = 10 * time.Second
+)
+
+// FallbackMember is one engine in a fallback chain. Timeout bounds a whole
+// call, or for streams the wait for the first token; zero means no limit.
+type FallbackMember struct {
+	Engine  *Engine
+	Timeout time.Duration
+}
+
+// ServedBy identifies the provider that answered a call and, for fallback
+// chains, the attempts that failed before it.
+type ServedBy struct {
+	ConnectionID string            `json:"connection_id"`
+	Provider     AIProvider        `json:"provider"`
+	Model        string            `json:"model"`
+	Failures     []FallbackFailure `json:"failures,omitempty"`
+}
+
+type FallbackFailure struct {
+	ConnectionID string `json:"connection_id"`
+	Attempt      int    `json:"attempt"`
+	Error        string `json:"error"`
+}
+
+// FallbackProvider tries its members in order. Retryable errors (429, 5xx,
+// connection refused) are retried with exponential backoff before moving on;
+// other errors and timeouts move on at once.
+type FallbackProvider struct {
+	members        []FallbackMember
+	maxRetries     int
+	initialBackoff time.Duration
+}
+
+// NewFallbackEngine chains engines under one connection ID. Prompts are
+// sized for the member with the smallest context window so any member can
+// take over.
+func NewFallbackEngine(connectionID string, members []FallbackMember, maxRetries int, initialBackoff time.Duration) (*Engine, error) {
+	if len(members) == 0 {
+		return nil, errors.New("fallback chain needs at least one connection")
+	}
+	if initialBackoff <= 0 {
+		initialBackoff = DefaultFallbackBackoff
+	}
+
+	config := members[0].Engine.config
+	for _, m := range members[1:] {
+		if ContextSize(m.Engine.config.Provider, m.Engine.config.Model) < ContextSize(config.Provider, config.Model) {
+			config = m.Engine.config
+		}
+	}
+
+	return &Engine{
+		provider: &FallbackProvider{
+			members:        members,
+			maxRetries:     maxRetries,
+			initialBackoff: initialBackoff,
+		},
+		config:       config,
+		connectionID: connectionID,
+	}, nil
+}
+
+func (p *FallbackProvider) Generate(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
+	return p.try(ctx, func(ctx context.Context, m FallbackMember) (*GenerateResponse, bool, error) {
+		if m.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, m.Timeout)
+			defer cancel()
+		}
+		resp, err := m.Engine.GenerateText(ctx, req)
+		return resp, false, err
+	})
+}
+
+// GenerateStream only falls back before the first token: once output has
+// been streamed, switching providers would repeat or garble it.
+func (p *FallbackProvider) GenerateStream(ctx context.Context, req GenerateRequest, onToken func(string)) (*GenerateResponse, error) {
+	return p.try(ctx, func(ctx context.Context, m FallbackMember) (*GenerateResponse, bool, error) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		var timer *time.Timer
+		var timedOut atomic.Bool
+		if m.Timeout > 0 {
+			timer = time.AfterFunc(m.Timeout, func() {
+				timedOut.Store(true)
+				cancel()
+			})
+			defer timer.Stop()
+		}
+
+		started := false
+		resp, err := m.Engine.GenerateTextStream(ctx, req, func(token string) {
+			if !started {
+				started = true
+				if timer != nil {
+					timer.Stop()
+				}
+			}
+			onToken(token)
+		})
+		if err != nil && !started && timedOut.Load() {
+			err = fmt.Errorf("no response within %s", m.Timeout)
+		}
+		return resp, started, err
+	})
+}
+
+func (p *FallbackProvider) supportsNativeTools() bool {
+	for _, m := range p.members {
+		native, ok := m.Engine.provider.(nativeToolCaller)
+		if !ok || !native.supportsNativeTools() {
+			return false
+		}
+	}
+	return true
+}
+
+// try runs call against each member in turn. call reports whether output was
+// already delivered, in which case its error is final.
+func (p *FallbackProvider) try(ctx context.Context, call func(context.Context, FallbackMember) (*GenerateResponse, bool, error)) (*GenerateResponse, error) {
+	var failures []FallbackFailure
+	var lastErr error
+
+	for _, m := range p.members {
+		for attempt := 0; attempt <= p.maxRetries; attempt++ {
+			if attempt > 0 {
+				if err := sleepContext(ctx, p.backoff(attempt)); err != nil {
+					return nil, err
+				}
+			}
+
+			resp, delivered, err := call(ctx, m)
+			if err == nil {
+				resp.Served = &ServedBy{
+					ConnectionID: m.Engine.ConnectionID(),
+					Provider:     m.Engine.config.Provider,
+					Model:        m.Engine.config.Model,
+					Failures:     failures,
+				}
+				return resp, nil
+			}
+			if ctx.Err() != nil || delivered {
+				return nil, err
+			}
+
+			lastErr = err
+			failures = append(failures, FallbackFailure{ConnectionID: m.Engine.ConnectionID(), Attempt: attempt + 1, Error: err.Error()})
+			if !isRetryable(err) {
+				break
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("all %d providers failed, last error: %w", len(p.members), lastErr)
+}
+
+func (p *FallbackProvider) backoff(attempt int) time.Duration {
+	d := p.initialBackoff << (attempt - 1)
+	if d <= 0 || d > maxFallbackBackoff {
+		return maxFallbackBackoff
+	}
+	return d
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ollamaStatus matches the "503 Service Unavailable" status text langchaingo
+// reports for Ollama HTTP errors; its error type is not exported.
+var ollamaStatus = regexp.MustCompile(`^(\d{3}) `)
+
+// isRetryable reports whether err is worth retrying on the same provider:
+// rate limiting, server errors and refused connections.
+func isRetryable(err error) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	status := 0
+	var openaiAPIErr *openai.APIError
+	var openaiReqErr *openai.RequestError
+	var anthropicErr *anthropic.Error
+	switch {
+	case errors.As(err, &openaiAPIErr):
+		status = openaiAPIErr.HTTPStatusCode
+	case errors.As(err, &openaiReqErr):
+		status = openaiReqErr.HTTPStatusCode
+	case errors.As(err, &anthropicErr):
+		status = anthropicErr.StatusCode
+	default:
+		if m := ollamaStatus.FindStringSubmatch(err.Error()); m != nil {
+			status, _ = strconv.Atoi(m[1])
+		} else if strings.Contains(err.Error(), "connection refused") {
+			return true
+		}
+	}
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
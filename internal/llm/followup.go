@@ -0,0 +1,60 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+const followUpSystemPrompt = analystSystemPrompt + `
+
+You already explained this trace. Answer the engineer's follow-up questions using the trace, facts and system context given, and the conversation so far. When asked "what if", reason from the observed timings and dependencies, and say what data would confirm the prediction.`
+
+// minContextShare is the part of the prompt budget kept for trace context
+// before older conversation turns are dropped.
+const minContextShare = 0.5
+
+// AnswerFollowUp answers a question about an analyzed trace, streaming the
+// answer. history holds the prior turns, oldest first; the oldest are
+// dropped when the conversation would crowd the trace out of the context.
+func (e *Engine) AnswerFollowUp(ctx context.Context, analysis models.TraceAnalysis, history []Message, question string, onToken func(string)) (*GenerateResponse, error) {
+	budget := promptBudget(e.config, GenerateRequest{})
+
+	turns := append(append([]Message(nil), history...), Message{Role: RoleUser, Content: question})
+	for len(turns) > 1 && float64(messageTokens(turns)) > float64(budget)*(1-minContextShare) {
+		// Drop a user/assistant pair so the history keeps alternating.
+		drop := 2
+		if len(turns)-drop < 1 {
+			drop = len(turns) - 1
+		}
+		turns = turns[drop:]
+	}
+
+	req, ref, err := buildContextPrompt(analysis, budget-messageTokens(turns), PromptFollowUp, false)
+	if err != nil {
+		return nil, err
+	}
+	// Most providers expect turns to alternate starting from the user, so a
+	// history opening with the assistant's explanation follows the context directly.
+	if turns[0].Role == RoleAssistant {
+		req.Messages = append(req.Messages, turns...)
+	} else {
+		req.Messages = append(req.Messages, Message{Role: RoleAssistant, Content: "Understood. What would you like to know about this trace?"})
+		req.Messages = append(req.Messages, turns...)
+	}
+
+	resp, err := e.GenerateTextStream(ctx, req, onToken)
+	if err != nil {
+		return nil, err
+	}
+	resp.Prompt = &ref
+	return resp, nil
+}
+
+func messageTokens(messages []Message) int {
+	n := 0
+	for _, m := range messages {
+		n += CountTokens(m.Content)
+	}
+	return n
+}
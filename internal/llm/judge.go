@@ -0,0 +1,230 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+// MaxJudgePasses bounds the judge passes of one evaluation.
+const MaxJudgePasses = 5
+
+// judgeSamplingTemperature lets repeated passes disagree, so their spread
+// shows how stable the verdict is. A single pass is deterministic.
+const judgeSamplingTemperature = 0.7
+
+const judgeSystemPrompt = `You are a Senior SRE Auditor. Evaluate AI-generated incident explanations based on technical correctness and causal logic.
+
+Task:
+Score the explanation from 1-10 on 'Causal Correctness'.
+Explain why you gave that score.
+Check if the explanation identified the root cause mentioned in the symbolic facts.
+Also score from 1-10:
+- Completeness: does it cover what failed, how it propagated and its impact?
+- Actionability: are the remediation steps specific and prioritized?
+- Hallucination: 10 when every service, error and number it cites appears in the trace or facts; lower for each unsupported claim.
+
+Report your evaluation as JSON.`
+
+// judgeSchema mirrors models.JudgeVerdict.
+var judgeSchema = &JSONSchema{
+	Name: "judge_verdict",
+	Schema: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"score":            map[string]any{"type": "number", "description": "Causal correctness, from 1 to 10."},
+			"rationale":        map[string]any{"type": "string", "description": "Why the explanation got this score."},
+			"root_cause_found": map[string]any{"type": "boolean", "description": "True when the explanation identified the root cause."},
+			"criteria": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"completeness":  map[string]any{"type": "number", "description": "From 1 to 10."},
+					"actionability": map[string]any{"type": "number", "description": "From 1 to 10."},
+					"hallucination": map[string]any{"type": "number", "description": "From 1 to 10; 10 means no unsupported claims."},
+				},
+				"required":             []string{"completeness", "actionability", "hallucination"},
+				"additionalProperties": false,
+			},
+		},
+		"required":             []string{"score", "rationale", "root_cause_found", "criteria"},
+		"additionalProperties": false,
+	},
+}
+
+// EvaluateExplanation uses LLM-as-a-Judge to score an explanation, running
+// passes judge passes in parallel (at most MaxJudgePasses) and aggregating
+// them. Passes that fail are left out; it fails only when all of them do.
+// The response carries the combined usage of every pass.
+func (e *Engine) EvaluateExplanation(ctx context.Context, trace models.Trace, facts []models.SymbolicFact, explanation string, passes int) (*models.Evaluation, *GenerateResponse, error) {
+	if passes < 1 {
+		passes = 1
+	}
+	if passes > MaxJudgePasses {
+		passes = MaxJudgePasses
+	}
+
+	req, ref, err := renderPrompt(PromptJudge, JudgePromptData{Trace: trace, Facts: facts, Explanation: explanation})
+	if err != nil {
+		return nil, nil, err
+	}
+	req.ResponseFormat = ResponseFormatJSON
+	req.JSONSchema = judgeSchema
+	req.Temperature = Float(0)
+	if passes > 1 {
+		req.Temperature = Float(judgeSamplingTemperature)
+	}
+
+	verdicts := make([]*models.JudgeVerdict, passes)
+	responses := make([]*GenerateResponse, passes)
+	errs := make([]error, passes)
+	var wg sync.WaitGroup
+	for i := 0; i < passes; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			verdicts[i], responses[i], errs[i] = e.judgeOnce(ctx, req)
+		}(i)
+	}
+	wg.Wait()
+
+	combined := &GenerateResponse{Prompt: &ref}
+	var ok []models.JudgeVerdict
+	for i := range verdicts {
+		if responses[i] != nil {
+			combined.Usage = addUsage(combined.Usage, responses[i].Usage)
+			combined.Served = responses[i].Served
+		}
+		if verdicts[i] != nil {
+			ok = append(ok, *verdicts[i])
+			combined.Content = responses[i].Content
+		}
+	}
+	if len(ok) == 0 {
+		return nil, combined, errors.Join(errs...)
+	}
+	return aggregateVerdicts(ok), combined, nil
+}
+
+// judgeOnce runs one judge pass, sending invalid output back for repair up
+// to maxStructuredAttempts in total. The response carries the usage of
+// every attempt, even when the pass fails.
+func (e *Engine) judgeOnce(ctx context.Context, req GenerateRequest) (*models.JudgeVerdict, *GenerateResponse, error) {
+	req.Messages = append([]Message(nil), req.Messages...)
+
+	var last *GenerateResponse
+	var usage Usage
+	var problems []string
+	for attempt := 1; attempt <= maxStructuredAttempts; attempt++ {
+		resp, err := e.GenerateText(ctx, req)
+		if err != nil {
+			if last != nil {
+				last.Usage = usage
+			}
+			return nil, last, err
+		}
+		usage = addUsage(usage, resp.Usage)
+		last = resp
+
+		var verdict *models.JudgeVerdict
+		verdict, problems = parseJudgeVerdict(resp.Content)
+		if len(problems) == 0 {
+			resp.Usage = usage
+			return verdict, resp, nil
+		}
+
+		req.Messages = append(req.Messages,
+			Message{Role: RoleAssistant, Content: resp.Content},
+			Message{Role: RoleUser, Content: "Your response did not match the required JSON schema:\n- " + strings.Join(problems, "\n- ") + "\n\nReturn the corrected JSON object only."},
+		)
+	}
+
+	last.Usage = usage
+	return nil, last, fmt.Errorf("no valid judge verdict after %d attempts: %s", maxStructuredAttempts, strings.Join(problems, "; "))
+}
+
+// parseJudgeVerdict decodes and validates judge output, returning every
+// problem found so one repair round can fix them all.
+func parseJudgeVerdict(content string) (*models.JudgeVerdict, []string) {
+	data := []byte(extractJSON(content))
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, []string{fmt.Sprintf("response is not a JSON object: %v", err)}
+	}
+
+	var problems []string
+	for _, key := range judgeSchema.Schema["required"].([]string) {
+		if _, ok := fields[key]; !ok {
+			problems = append(problems, fmt.Sprintf("missing required field %q", key))
+		}
+	}
+
+	var verdict models.JudgeVerdict
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&verdict); err != nil {
+		return nil, append(problems, fmt.Sprintf("invalid field: %v", err))
+	}
+
+	scores := []struct {
+		name  string
+		value float64
+	}{
+		{"score", verdict.Score},
+		{"criteria.completeness", verdict.Criteria.Completeness},
+		{"criteria.actionability", verdict.Criteria.Actionability},
+		{"criteria.hallucination", verdict.Criteria.Hallucination},
+	}
+	for _, s := range scores {
+		if s.value < 1 || s.value > 10 {
+			problems = append(problems, fmt.Sprintf("%s must be between 1 and 10, got %v", s.name, s.value))
+		}
+	}
+	if strings.TrimSpace(verdict.Rationale) == "" {
+		problems = append(problems, "rationale must not be empty")
+	}
+
+	if len(problems) > 0 {
+		return nil, problems
+	}
+	return &verdict, nil
+}
+
+func aggregateVerdicts(passes []models.JudgeVerdict) *models.Evaluation {
+	n := float64(len(passes))
+	var mean models.JudgeVerdict
+	found := 0
+	for _, p := range passes {
+		mean.Score += p.Score / n
+		mean.Criteria.Completeness += p.Criteria.Completeness / n
+		mean.Criteria.Actionability += p.Criteria.Actionability / n
+		mean.Criteria.Hallucination += p.Criteria.Hallucination / n
+		if p.RootCauseFound {
+			found++
+		}
+	}
+	mean.RootCauseFound = found*2 > len(passes)
+
+	var variance float64
+	closest := passes[0]
+	for _, p := range passes {
+		variance += (p.Score - mean.Score) * (p.Score - mean.Score) / n
+		if math.Abs(p.Score-mean.Score) < math.Abs(closest.Score-mean.Score) {
+			closest = p
+		}
+	}
+	mean.Rationale = closest.Rationale
+
+	return &models.Evaluation{
+		JudgeVerdict: mean,
+		ScoreStdDev:  math.Sqrt(variance),
+		Passes:       passes,
+	}
+}
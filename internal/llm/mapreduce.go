@@ -0,0 +1,473 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+// DefaultMapReduceConcurrency bounds parallel subtree explanations when the
+// caller does not set a limit.
+const DefaultMapReduceConcurrency = 4
+
+// partialMaxTokens caps each subtree explanation so the synthesis prompt stays small.
+const partialMaxTokens = 512
+
+// minChunkTokens is the least room for spans a chunk prompt must leave
+// after the trace context; with less, chunks would hold a span or two
+// each and a large trace would cost one call per span.
+const minChunkTokens = 1024
+
+// PartialAnalysis is the explanation of one chunk of a trace's span tree.
+type PartialAnalysis struct {
+	Index    int      `json:"index"`
+	Roots    []string `json:"roots"`
+	Spans    int      `json:"spans"`
+	Analysis string   `json:"analysis,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// MapReduceProgress is reported as each chunk finishes and when synthesis starts.
+type MapReduceProgress struct {
+	Stage     string           `json:"stage"`
+	Completed int              `json:"completed"`
+	Total     int              `json:"total"`
+	Partial   *PartialAnalysis `json:"partial,omitempty"`
+}
+
+const (
+	StageMap    = "map"
+	StageReduce = "reduce"
+)
+
+const subtreeSystemPrompt = `You are an expert SRE Agent analyzing one part of a very large OTel trace. Other parts are analyzed separately and combined later.
+
+Task:
+Summarize what happens in this part: failing operations, slow operations, and suspicious patterns such as retries or fan-out. Relate them to the trace-level facts where relevant.
+
+Be technical and concise (under 150 words). Do not speculate about parts of the trace you cannot see.`
+
+const combineSystemPrompt = `You are an expert SRE Agent combining partial analyses of adjacent parts of a very large OTel trace. Other parts are combined separately.
+
+Task:
+Merge these analyses into one summary of the parts they cover, keeping every failing operation, slow operation and suspicious pattern they report.
+
+Be technical and concise (under 200 words). Do not add findings the analyses do not contain.`
+
+const synthesisSystemPrompt = analystSystemPrompt + `
+
+The trace was too large to show at once. You are given the trace-level context, its critical path, and partial analyses of each part of its span tree. Combine them into one explanation of the whole trace.`
+
+// subtreeTemplate is rendered with an AnalysisPromptData whose Spans lists
+// one part of the trace.
+const subtreeTemplate = contextSections + `
+### Spans in This Part:
+{{.Spans}}`
+
+// combineTemplate is rendered with a SynthesisPromptData.
+const combineTemplate = contextSections + `
+### Partial Analyses to Combine:
+{{.Partials}}`
+
+// synthesisTemplate is rendered with a SynthesisPromptData.
+const synthesisTemplate = contextSections + `
+### Critical Path ({{len .Trace.Spans}} spans in trace):
+{{.CriticalPath}}
+### Partial Analyses:
+{{.Partials}}`
+
+// ExplainTraceMapReduce explains a trace too large for one prompt: its span
+// tree is split into chunks that fit the model's context, each chunk is
+// explained in parallel with at most concurrency calls in flight, and the
+// partial analyses are synthesized into a final streamed explanation. When
+// the partial analyses together do not fit, they are first combined in
+// batches, level by level, until they do.
+func (e *Engine) ExplainTraceMapReduce(ctx context.Context, analysis models.TraceAnalysis, concurrency int, onProgress func(MapReduceProgress), onToken func(string)) (*GenerateResponse, error) {
+	if concurrency <= 0 {
+		concurrency = DefaultMapReduceConcurrency
+	}
+
+	partialBudget := promptBudget(e.config, GenerateRequest{MaxTokens: partialMaxTokens})
+	analysis = fitRetrieved(analysis, int(float64(partialBudget)*retrievedShare))
+
+	var header string
+	var chunkData AnalysisPromptData
+	var chunkBudget int
+	// measure renders the chunk prompt without spans to see what the
+	// template and context cost.
+	measure := func() error {
+		header = renderContext(analysis)
+		chunkData = AnalysisPromptData{TraceAnalysis: analysis, Context: header}
+		base, _, err := renderPrompt(PromptSubtree, chunkData)
+		if err != nil {
+			return err
+		}
+		chunkBudget = partialBudget - CountTokens(base.System) - messageTokens(base.Messages)
+		return nil
+	}
+	if err := measure(); err != nil {
+		return nil, err
+	}
+	if chunkBudget < minChunkTokens && (len(analysis.SimilarIncidents) > 0 || len(analysis.Runbooks) > 0) {
+		analysis.SimilarIncidents, analysis.Runbooks = nil, nil
+		if err := measure(); err != nil {
+			return nil, err
+		}
+	}
+	if chunkBudget < minChunkTokens {
+		return nil, fmt.Errorf("trace context leaves %d of %d prompt tokens for spans; splitting the trace needs at least %d", max(chunkBudget, 0), partialBudget, minChunkTokens)
+	}
+
+	tree := newSpanTree(analysis.Trace)
+	chunks := tree.partition(chunkBudget)
+	partials := make([]PartialAnalysis, len(chunks))
+
+	var (
+		mu        sync.Mutex
+		usage     Usage
+		completed int
+		wg        sync.WaitGroup
+	)
+	sem := make(chan struct{}, concurrency)
+
+	for i, chunk := range chunks {
+		partials[i] = PartialAnalysis{Index: i, Roots: tree.chunkRoots(chunk), Spans: tree.chunkSize(chunk)}
+
+		wg.Add(1)
+		go func(i int, chunk []spanUnit) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				mu.Lock()
+				partials[i].Error = ctx.Err().Error()
+				mu.Unlock()
+				return
+			}
+
+			data := chunkData
+			data.Spans = tree.renderChunk(chunk)
+			resp, err := e.generatePartial(ctx, PromptSubtree, data)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				partials[i].Error = err.Error()
+			} else {
+				partials[i].Analysis = strings.TrimSpace(resp.Content)
+				usage = addUsage(usage, resp.Usage)
+			}
+			completed++
+			partial := partials[i]
+			onProgress(MapReduceProgress{Stage: StageMap, Completed: completed, Total: len(chunks), Partial: &partial})
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	failed := 0
+	for _, p := range partials {
+		if p.Error != "" {
+			failed++
+		}
+	}
+	if failed == len(partials) {
+		return nil, errors.New("all subtree analyses failed: " + partials[0].Error)
+	}
+
+	var criticalPath strings.Builder
+	for depth, i := range tree.criticalPath() {
+		criticalPath.WriteString(strings.Repeat("  ", depth) + tree.line(i) + "\n")
+	}
+	data := SynthesisPromptData{TraceAnalysis: analysis, Context: header, CriticalPath: criticalPath.String()}
+
+	partials, reduceUsage, err := e.reducePartials(ctx, data, partials, concurrency, onProgress)
+	if err != nil {
+		return nil, err
+	}
+	usage = addUsage(usage, reduceUsage)
+
+	onProgress(MapReduceProgress{Stage: StageReduce, Completed: len(chunks) - failed, Total: len(chunks)})
+
+	data.Partials = renderPartials(partials)
+	req, ref, err := renderPrompt(PromptSynthesis, data)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := e.GenerateTextStream(ctx, req, onToken)
+	if err != nil {
+		return nil, err
+	}
+	resp.Usage = addUsage(usage, resp.Usage)
+	resp.Prompt = &ref
+	return resp, nil
+}
+
+// generatePartial renders a map or combine prompt and explains it with the
+// output capped at partialMaxTokens.
+func (e *Engine) generatePartial(ctx context.Context, name string, data any) (*GenerateResponse, error) {
+	req, _, err := renderPrompt(name, data)
+	if err != nil {
+		return nil, err
+	}
+	req.MaxTokens = partialMaxTokens
+	return e.GenerateText(ctx, req)
+}
+
+// reducePartials combines batches of partial analyses into fewer, broader
+// ones until the synthesis prompt fits the model's context. Each level
+// reports a reduce progress event per combined batch.
+func (e *Engine) reducePartials(ctx context.Context, data SynthesisPromptData, partials []PartialAnalysis, concurrency int, onProgress func(MapReduceProgress)) ([]PartialAnalysis, Usage, error) {
+	var usage Usage
+
+	combineBase, _, err := renderPrompt(PromptCombine, data)
+	if err != nil {
+		return nil, usage, err
+	}
+	batchBudget := promptBudget(e.config, GenerateRequest{MaxTokens: partialMaxTokens}) - CountTokens(combineBase.System) - messageTokens(combineBase.Messages)
+
+	for len(partials) > 1 {
+		synthesisData := data
+		synthesisData.Partials = renderPartials(partials)
+		synthesis, _, err := renderPrompt(PromptSynthesis, synthesisData)
+		if err != nil {
+			return nil, usage, err
+		}
+		if CountTokens(synthesis.System)+messageTokens(synthesis.Messages) <= promptBudget(e.config, synthesis) {
+			break
+		}
+
+		batches := batchPartials(partials, batchBudget)
+		combined := make([]PartialAnalysis, len(batches))
+		var (
+			mu        sync.Mutex
+			completed int
+			wg        sync.WaitGroup
+		)
+		sem := make(chan struct{}, concurrency)
+
+		for i, batch := range batches {
+			combined[i] = mergePartials(i, batch)
+
+			wg.Add(1)
+			go func(i int, batch []PartialAnalysis) {
+				defer wg.Done()
+
+				select {
+				case sem <- struct{}{}:
+					defer func() { <-sem }()
+				case <-ctx.Done():
+					return
+				}
+
+				batchData := data
+				batchData.Partials = renderPartials(batch)
+				resp, err := e.generatePartial(ctx, PromptCombine, batchData)
+
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					combined[i].Error = err.Error()
+				} else {
+					combined[i].Analysis = strings.TrimSpace(resp.Content)
+					usage = addUsage(usage, resp.Usage)
+				}
+				completed++
+				partial := combined[i]
+				onProgress(MapReduceProgress{Stage: StageReduce, Completed: completed, Total: len(batches), Partial: &partial})
+			}(i, batch)
+		}
+		wg.Wait()
+
+		if err := ctx.Err(); err != nil {
+			return nil, usage, err
+		}
+		failed := 0
+		for _, p := range combined {
+			if p.Error != "" {
+				failed++
+			}
+		}
+		if failed == len(combined) {
+			return nil, usage, errors.New("all partial analysis reductions failed: " + combined[0].Error)
+		}
+		partials = combined
+	}
+	return partials, usage, nil
+}
+
+// batchPartials groups consecutive partials into batches whose analyses fit
+// budget. Every batch but possibly the last holds at least two partials so
+// that each level of reduction makes progress.
+func batchPartials(partials []PartialAnalysis, budget int) [][]PartialAnalysis {
+	var batches [][]PartialAnalysis
+	var current []PartialAnalysis
+	used := 0
+	for _, p := range partials {
+		n := CountTokens(renderPartials([]PartialAnalysis{p}))
+		if len(current) >= 2 && used+n > budget {
+			batches = append(batches, current)
+			current, used = nil, 0
+		}
+		current = append(current, p)
+		used += n
+	}
+	if len(current) == 1 && len(batches) > 0 {
+		batches[len(batches)-1] = append(batches[len(batches)-1], current[0])
+	} else if len(current) > 0 {
+		batches = append(batches, current)
+	}
+	return batches
+}
+
+// mergePartials describes the part of the trace a batch of partials covers,
+// ahead of its combined analysis.
+func mergePartials(index int, batch []PartialAnalysis) PartialAnalysis {
+	merged := PartialAnalysis{Index: index}
+	seen := make(map[string]bool)
+	for _, p := range batch {
+		merged.Spans += p.Spans
+		for _, r := range p.Roots {
+			if !seen[r] {
+				seen[r] = true
+				merged.Roots = append(merged.Roots, r)
+			}
+		}
+	}
+	return merged
+}
+
+func renderPartials(partials []PartialAnalysis) string {
+	var sb strings.Builder
+	for _, p := range partials {
+		sb.WriteString(fmt.Sprintf("\n#### Part %d (%d spans under %s)\n", p.Index+1, p.Spans, strings.Join(p.Roots, ", ")))
+		if p.Error != "" {
+			sb.WriteString("(analysis unavailable)\n")
+			continue
+		}
+		sb.WriteString(p.Analysis + "\n")
+	}
+	return sb.String()
+}
+
+func addUsage(a, b Usage) Usage {
+	return Usage{
+		PromptTokens:     a.PromptTokens + b.PromptTokens,
+		CompletionTokens: a.CompletionTokens + b.CompletionTokens,
+		TotalTokens:      a.TotalTokens + b.TotalTokens,
+		EstimatedCostUSD: a.EstimatedCostUSD + b.EstimatedCostUSD,
+	}
+}
+
+// spanUnit is a span in a chunk, either with its whole subtree or, when the
+// subtree was too large and got split, on its own.
+type spanUnit struct {
+	span    int
+	subtree bool
+}
+
+// partition splits the tree into chunks of units whose listings fit the
+// budget, walking in depth-first order so related spans stay together.
+// Subtrees larger than the budget are split into their children.
+func (t *spanTree) partition(budget int) [][]spanUnit {
+	tokens := make([]int, len(t.spans))
+	var size func(i int) int
+	size = func(i int) int {
+		n := CountTokens(t.line(i) + "\n")
+		for _, c := range t.children[i] {
+			n += size(c)
+		}
+		tokens[i] = n
+		return n
+	}
+	for _, r := range t.roots {
+		size(r)
+	}
+
+	var chunks [][]spanUnit
+	var current []spanUnit
+	used := 0
+	add := func(u spanUnit, n int) {
+		if len(current) > 0 && used+n > budget {
+			chunks = append(chunks, current)
+			current, used = nil, 0
+		}
+		current = append(current, u)
+		used += n
+	}
+
+	var visit func(i int)
+	visit = func(i int) {
+		if tokens[i] <= budget || len(t.children[i]) == 0 {
+			add(spanUnit{span: i, subtree: true}, tokens[i])
+			return
+		}
+		add(spanUnit{span: i}, CountTokens(t.line(i)+"\n"))
+		for _, c := range t.children[i] {
+			visit(c)
+		}
+	}
+	for _, r := range t.roots {
+		visit(r)
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+func (t *spanTree) renderChunk(chunk []spanUnit) string {
+	var sb strings.Builder
+	var walk func(i, depth int)
+	walk = func(i, depth int) {
+		sb.WriteString(strings.Repeat("  ", depth) + t.line(i) + "\n")
+		for _, c := range t.children[i] {
+			walk(c, depth+1)
+		}
+	}
+
+	for _, u := range chunk {
+		if p := t.parent[u.span]; p >= 0 {
+			sb.WriteString(fmt.Sprintf("(child of %s)\n", t.spans[p].Name))
+		}
+		if u.subtree {
+			walk(u.span, 0)
+		} else {
+			sb.WriteString(t.line(u.span) + " (children listed separately)\n")
+		}
+	}
+	return sb.String()
+}
+
+func (t *spanTree) chunkRoots(chunk []spanUnit) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, u := range chunk {
+		name := t.spans[u.span].Name
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (t *spanTree) chunkSize(chunk []spanUnit) int {
+	n := 0
+	for _, u := range chunk {
+		n++
+		if u.subtree {
+			n += t.descendants(u.span)
+		}
+	}
+	return n
+}
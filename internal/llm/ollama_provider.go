@@ -33,26 +33,87 @@ func NewOllamaProvider(endpoint, model string) (*OllamaProvider, error) {
 	}, nil
 }
 
-func (p *OllamaProvider) Generate(ctx context.Context, prompt string) (string, error) {
-	completion, err := llms.GenerateFromSinglePrompt(ctx, p.llm, prompt)
+func (p *OllamaProvider) buildRequest(req GenerateRequest) ([]llms.MessageContent, []llms.CallOption) {
+	// langchaingo only exposes Ollama's plain JSON format, so schemas are
+	// passed as an instruction.
+	system := req.System
+	if req.JSONSchema != nil {
+		system = systemWithJSONHint(req)
+	}
+
+	messages := make([]llms.MessageContent, 0, len(req.Messages)+1)
+	if system != "" {
+		messages = append(messages, llms.TextParts(llms.ChatMessageTypeSystem, system))
+	}
+	for _, m := range req.Messages {
+		msgType := llms.ChatMessageTypeHuman
+		if m.Role == RoleAssistant {
+			msgType = llms.ChatMessageTypeAI
+		}
+		messages = append(messages, llms.TextParts(msgType, m.Content))
+	}
+
+	var opts []llms.CallOption
+	if req.Temperature != nil {
+		opts = append(opts, llms.WithTemperature(*req.Temperature))
+	}
+	if req.MaxTokens > 0 {
+		opts = append(opts, llms.WithMaxTokens(req.MaxTokens))
+	}
+	if len(req.StopSequences) > 0 {
+		opts = append(opts, llms.WithStopWords(req.StopSequences))
+	}
+	if req.Seed != nil {
+		opts = append(opts, llms.WithSeed(*req.Seed))
+	}
+	if req.ResponseFormat == ResponseFormatJSON {
+		opts = append(opts, llms.WithJSONMode())
+	}
+	return messages, opts
+}
+
+func (p *OllamaProvider) Generate(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
+	messages, opts := p.buildRequest(req)
+
+	resp, err := p.llm.GenerateContent(ctx, messages, opts...)
 	if err != nil {
-		return "", fmt.Errorf("ollama generation failed: %w", err)
+		return nil, fmt.Errorf("ollama generation failed: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from ollama")
 	}
 
-	return completion, nil
+	return &GenerateResponse{
+		Content: resp.Choices[0].Content,
+		Usage:   ollamaUsage(resp.Choices[0]),
+	}, nil
 }
 
-func (p *OllamaProvider) GenerateStream(ctx context.Context, prompt string, onToken func(string)) error {
-	_, err := llms.GenerateFromSinglePrompt(ctx, p.llm, prompt,
-		llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
-			onToken(string(chunk))
-			return nil
-		}),
-	)
+func (p *OllamaProvider) GenerateStream(ctx context.Context, req GenerateRequest, onToken func(string)) (*GenerateResponse, error) {
+	messages, opts := p.buildRequest(req)
+	opts = append(opts, llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+		onToken(string(chunk))
+		return nil
+	}))
 
+	resp, err := p.llm.GenerateContent(ctx, messages, opts...)
 	if err != nil {
-		return fmt.Errorf("ollama stream failed: %w", err)
+		return nil, fmt.Errorf("ollama stream failed: %w", err)
+	}
+
+	result := &GenerateResponse{}
+	if len(resp.Choices) > 0 {
+		result.Content = resp.Choices[0].Content
+		result.Usage = ollamaUsage(resp.Choices[0])
 	}
+	return result, nil
+}
 
-	return nil
+// ollamaUsage reads the token counts langchaingo copies from Ollama's
+// prompt_eval_count and eval_count.
+func ollamaUsage(choice *llms.ContentChoice) Usage {
+	prompt, _ := choice.GenerationInfo["PromptTokens"].(int)
+	completion, _ := choice.GenerationInfo["CompletionTokens"].(int)
+	return newUsage(prompt, completion)
 }
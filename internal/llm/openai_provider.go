@@ -2,8 +2,11 @@ package llm
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"math"
+	"strings"
 
 	"github.com/sashabaranov/go-openai"
 )
@@ -31,61 +34,172 @@ func NewOpenAIProvider(endpoint, model, apiKey string) (*OpenAIProvider, error)
 	}, nil
 }
 
-func (p *OpenAIProvider) Generate(ctx context.Context, prompt string) (string, error) {
-	resp, err := p.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model: p.model,
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: prompt,
+// jsonSchemaModelPrefixes lists the model families that accept
+// response_format json_schema; other models fall back to json_object.
+var jsonSchemaModelPrefixes = []string{"gpt-4o", "gpt-4.1", "gpt-5", "o1", "o3", "o4"}
+
+func (p *OpenAIProvider) supportsJSONSchema() bool {
+	for _, prefix := range jsonSchemaModelPrefixes {
+		if strings.HasPrefix(p.model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaMarshaler adapts a schema map to the json.Marshaler go-openai expects.
+type schemaMarshaler map[string]any
+
+func (s schemaMarshaler) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any(s))
+}
+
+func (p *OpenAIProvider) buildRequest(req GenerateRequest) openai.ChatCompletionRequest {
+	nativeSchema := req.ResponseFormat == ResponseFormatJSON && req.JSONSchema != nil && p.supportsJSONSchema()
+
+	system := req.System
+	if req.JSONSchema != nil && !nativeSchema {
+		system = systemWithJSONHint(req)
+	}
+
+	messages := make([]openai.ChatCompletionMessage, 0, len(req.Messages)+1)
+	if system != "" {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: system,
+		})
+	}
+	for _, m := range req.Messages {
+		msg := openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleUser,
+			Content: m.Content,
+		}
+		switch m.Role {
+		case RoleAssistant:
+			msg.Role = openai.ChatMessageRoleAssistant
+			for _, call := range m.ToolCalls {
+				msg.ToolCalls = append(msg.ToolCalls, openai.ToolCall{
+					ID:   call.ID,
+					Type: openai.ToolTypeFunction,
+					Function: openai.FunctionCall{
+						Name:      call.Name,
+						Arguments: string(call.Arguments),
+					},
+				})
+			}
+		case RoleTool:
+			msg.Role = openai.ChatMessageRoleTool
+			msg.ToolCallID = m.ToolCallID
+		}
+		messages = append(messages, msg)
+	}
+
+	chatReq := openai.ChatCompletionRequest{
+		Model:     p.model,
+		Messages:  messages,
+		MaxTokens: req.MaxTokens,
+		Stop:      req.StopSequences,
+		Seed:      req.Seed,
+	}
+	if req.Temperature != nil {
+		// go-openai omits a zero temperature, so send the smallest positive value instead.
+		chatReq.Temperature = float32(math.Max(*req.Temperature, math.SmallestNonzeroFloat32))
+	}
+	for _, tool := range req.Tools {
+		chatReq.Tools = append(chatReq.Tools, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
 			},
-		},
-	})
+		})
+	}
+	if req.DisableTools && len(chatReq.Tools) > 0 {
+		chatReq.ToolChoice = "none"
+	}
+
+	switch {
+	case nativeSchema:
+		chatReq.ResponseFormat = &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+				Name:   req.JSONSchema.Name,
+				Schema: schemaMarshaler(req.JSONSchema.Schema),
+				Strict: true,
+			},
+		}
+	case req.ResponseFormat == ResponseFormatJSON:
+		chatReq.ResponseFormat = &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		}
+	}
+	return chatReq
+}
+
+func (p *OpenAIProvider) Generate(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
+	resp, err := p.client.CreateChatCompletion(ctx, p.buildRequest(req))
 
 	if err != nil {
-		return "", fmt.Errorf("openai generation failed: %w", err)
+		return nil, fmt.Errorf("openai generation failed: %w", err)
 	}
 
 	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("no response from openai")
+		return nil, fmt.Errorf("no response from openai")
 	}
 
-	return resp.Choices[0].Message.Content, nil
+	result := &GenerateResponse{
+		Content: resp.Choices[0].Message.Content,
+		Usage:   newUsage(resp.Usage.PromptTokens, resp.Usage.CompletionTokens),
+	}
+	for _, call := range resp.Choices[0].Message.ToolCalls {
+		result.ToolCalls = append(result.ToolCalls, ToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: json.RawMessage(call.Function.Arguments),
+		})
+	}
+	return result, nil
 }
 
-func (p *OpenAIProvider) GenerateStream(ctx context.Context, prompt string, onToken func(string)) error {
-	stream, err := p.client.CreateChatCompletionStream(ctx, openai.ChatCompletionRequest{
-		Model: p.model,
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: prompt,
-			},
-		},
-		Stream: true,
-	})
+func (p *OpenAIProvider) supportsNativeTools() bool { return true }
+
+func (p *OpenAIProvider) GenerateStream(ctx context.Context, req GenerateRequest, onToken func(string)) (*GenerateResponse, error) {
+	chatReq := p.buildRequest(req)
+	chatReq.Stream = true
+	// Usage is only reported on the final chunk when explicitly requested.
+	chatReq.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+
+	stream, err := p.client.CreateChatCompletionStream(ctx, chatReq)
 
 	if err != nil {
-		return fmt.Errorf("openai stream creation failed: %w", err)
+		return nil, fmt.Errorf("openai stream creation failed: %w", err)
 	}
 	defer stream.Close()
 
+	var content strings.Builder
+	result := &GenerateResponse{}
 	for {
 		response, err := stream.Recv()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			return fmt.Errorf("stream error: %w", err)
+			return nil, fmt.Errorf("stream error: %w", err)
 		}
 
+		if response.Usage != nil {
+			result.Usage = newUsage(response.Usage.PromptTokens, response.Usage.CompletionTokens)
+		}
 		if len(response.Choices) > 0 {
-			content := response.Choices[0].Delta.Content
-			if content != "" {
-				onToken(content)
+			token := response.Choices[0].Delta.Content
+			if token != "" {
+				content.WriteString(token)
+				onToken(token)
 			}
 		}
 	}
 
-	return nil
+	result.Content = content.String()
+	return result, nil
 }
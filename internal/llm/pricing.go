@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ModelPrice is the USD price per million tokens.
+type ModelPrice struct {
+	InputPerMillion  float64 `json:"input_per_million"`
+	OutputPerMillion float64 `json:"output_per_million"`
+}
+
+var (
+	pricesMu sync.RWMutex
+	// prices is keyed by model name prefix; the longest matching prefix wins.
+	prices = map[string]ModelPrice{
+		"gpt-5":             {InputPerMillion: 1.25, OutputPerMillion: 10.00},
+		"gpt-5-mini":        {InputPerMillion: 0.25, OutputPerMillion: 2.00},
+		"gpt-5-nano":        {InputPerMillion: 0.05, OutputPerMillion: 0.40},
+		"gpt-4.1":           {InputPerMillion: 2.00, OutputPerMillion: 8.00},
+		"gpt-4.1-mini":      {InputPerMillion: 0.40, OutputPerMillion: 1.60},
+		"gpt-4.1-nano":      {InputPerMillion: 0.10, OutputPerMillion: 0.40},
+		"gpt-4o-mini":       {InputPerMillion: 0.15, OutputPerMillion: 0.60},
+		"gpt-4o":            {InputPerMillion: 2.50, OutputPerMillion: 10.00},
+		"gpt-4-turbo":       {InputPerMillion: 10.00, OutputPerMillion: 30.00},
+		"gpt-4":             {InputPerMillion: 30.00, OutputPerMillion: 60.00},
+		"gpt-3.5-turbo":     {InputPerMillion: 0.50, OutputPerMillion: 1.50},
+		"o1":                {InputPerMillion: 15.00, OutputPerMillion: 60.00},
+		"o1-mini":           {InputPerMillion: 1.10, OutputPerMillion: 4.40},
+		"o1-pro":            {InputPerMillion: 150.00, OutputPerMillion: 600.00},
+		"o3":                {InputPerMillion: 2.00, OutputPerMillion: 8.00},
+		"o3-mini":           {InputPerMillion: 1.10, OutputPerMillion: 4.40},
+		"o3-pro":            {InputPerMillion: 20.00, OutputPerMillion: 80.00},
+		"o4-mini":           {InputPerMillion: 1.10, OutputPerMillion: 4.40},
+		"claude-3-opus":     {InputPerMillion: 15.00, OutputPerMillion: 75.00},
+		"claude-opus-4":     {InputPerMillion: 15.00, OutputPerMillion: 75.00},
+		"claude-3-5-sonnet": {InputPerMillion: 3.00, OutputPerMillion: 15.00},
+		"claude-3-7-sonnet": {InputPerMillion: 3.00, OutputPerMillion: 15.00},
+		"claude-sonnet-4":   {InputPerMillion: 3.00, OutputPerMillion: 15.00},
+		"claude-3-5-haiku":  {InputPerMillion: 0.80, OutputPerMillion: 4.00},
+		"claude-3-haiku":    {InputPerMillion: 0.25, OutputPerMillion: 1.25},
+	}
+
+	// unpriced records the models already reported as missing a price.
+	unpriced sync.Map
+)
+
+// LoadPriceTable merges prices from a JSON file of {"model-prefix": {...}}
+// into the built-in table.
+func LoadPriceTable(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read price table: %w", err)
+	}
+
+	var table map[string]ModelPrice
+	if err := json.Unmarshal(data, &table); err != nil {
+		return fmt.Errorf("failed to parse price table: %w", err)
+	}
+
+	pricesMu.Lock()
+	defer pricesMu.Unlock()
+	for model, price := range table {
+		prices[model] = price
+	}
+	return nil
+}
+
+// EstimateCost prices usage for a model. Local providers and unknown models cost zero.
+func EstimateCost(provider AIProvider, model string, u Usage) float64 {
+	if provider == ProviderOllama {
+		return 0
+	}
+
+	pricesMu.RLock()
+	defer pricesMu.RUnlock()
+
+	var best string
+	for prefix := range prices {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		if _, warned := unpriced.LoadOrStore(model, true); !warned {
+			log.Printf("No price for model %q; its usage is costed at zero (set LLM_PRICE_TABLE to price it)", model)
+		}
+		return 0
+	}
+
+	price := prices[best]
+	return (float64(u.PromptTokens)*price.InputPerMillion + float64(u.CompletionTokens)*price.OutputPerMillion) / 1e6
+}
@@ -0,0 +1,225 @@
+package llm
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+	"github.com/gigikoneti/tracemind/internal/prompt"
+)
+
+// Names of the prompt templates used by the engine. Each can be overridden
+// with a newer version through the prompt registry.
+const (
+	PromptRawAnalysis  = "raw_analysis"
+	PromptAnalysis     = "analysis"
+	PromptAnalysisJSON = "analysis_json"
+	PromptFollowUp     = "followup"
+	PromptJudge        = "judge"
+	PromptSubtree      = "mapreduce_subtree"
+	PromptCombine      = "mapreduce_combine"
+	PromptSynthesis    = "mapreduce_synthesis"
+)
+
+// rawAnalysisTemplate is rendered with an AnalysisPromptData.
+const rawAnalysisTemplate = `Analyze this OTel trace and explain what happened:
+
+{{range .Trace.Spans}}- {{.Name}}: {{.Status.Code}} [{{printf "%.2f" .LatencyMs}}ms]
+{{end}}`
+
+// contextSections is what trace templates show about a trace besides its
+// spans. It is rendered with an AnalysisPromptData or SynthesisPromptData.
+const contextSections = `### Global System Context (Symbolic Memory):
+- Overall Error Rate: {{printf "%.2f" (percent .SystemContext.RecentErrorRate)}}%
+{{- if .SystemContext.SlowestServices}}
+- Recent Latency Trends: Services {{join .SystemContext.SlowestServices ", "}} have been slow recently.
+{{- end}}
+
+### Symbolic Facts for This Trace:
+{{- range .SymbolicFacts}}
+- [{{.Severity}}] {{.Type}}: {{.Description}}
+{{- end}}
+{{- if .MetricAnomalies}}
+
+### Metric Anomalies During This Trace:
+{{- range .MetricAnomalies}}
+- {{.Description}}
+{{- end}}
+{{- end}}
+{{- if .RecentChanges}}
+
+### Recent Changes to Implicated Services:
+{{- range .RecentChanges}}
+- {{.Timestamp.Format "2006-01-02T15:04:05Z07:00"}} {{.Service}}: {{.Kind}}{{if .Version}} {{.Version}}{{end}}{{if .Author}} by {{.Author}}{{end}}{{if .Description}} ({{.Description}}){{end}}
+{{- end}}
+{{- end}}
+{{- if .SimilarIncidents}}
+
+### Previously Seen Similar Incidents:
+{{- range .SimilarIncidents}}
+- {{.AnalyzedAt.Format "2006-01-02T15:04:05Z07:00"}} (similarity {{printf "%.2f" .Score}}, trace {{.TraceID}}):
+  Explanation then: {{excerpt 600 .Explanation}}
+{{- end}}
+{{- end}}
+{{- if .Runbooks}}
+
+### Relevant Runbook Sections:
+Base remediation steps on these runbook sections where they apply, and cite the source of each such step as [file > heading].
+{{- range .Runbooks}}
+
+#### [{{.File}}{{if .Heading}} > {{.Heading}}{{end}}]
+{{.Content}}
+{{- end}}
+{{- end}}
+`
+
+// contextTemplate is rendered with an AnalysisPromptData.
+const contextTemplate = contextSections + `
+### OTel Spans:
+{{.Spans}}`
+
+// contextSectionsTemplate renders contextSections on its own, as Context
+// and to measure it.
+var contextSectionsTemplate = template.Must(prompt.Parse("context", contextSections))
+
+// renderContext renders contextSections for an analysis.
+func renderContext(analysis models.TraceAnalysis) string {
+	var sb strings.Builder
+	if err := contextSectionsTemplate.Execute(&sb, AnalysisPromptData{TraceAnalysis: analysis}); err != nil {
+		// The built-in sections only read fields every analysis has.
+		panic(err)
+	}
+	return sb.String()
+}
+
+// judgeTemplate is rendered with a JudgePromptData.
+const judgeTemplate = `Trace Data (Simplified):
+{{.Trace}}
+
+Computed Symbolic Facts:
+{{.Facts}}
+
+AI Explanation to Evaluate:
+"""
+{{.Explanation}}
+"""
+`
+
+func init() {
+	prompt.Register(PromptRawAnalysis, "", rawAnalysisTemplate, AnalysisPromptData{})
+	prompt.Register(PromptAnalysis, analystSystemPrompt, contextTemplate, AnalysisPromptData{})
+	prompt.Register(PromptAnalysisJSON, jsonAnalystSystemPrompt, contextTemplate, AnalysisPromptData{})
+	prompt.Register(PromptFollowUp, followUpSystemPrompt, contextTemplate, AnalysisPromptData{})
+	prompt.Register(PromptJudge, judgeSystemPrompt, judgeTemplate, JudgePromptData{})
+	prompt.Register(PromptSubtree, subtreeSystemPrompt, subtreeTemplate, AnalysisPromptData{})
+	prompt.Register(PromptCombine, combineSystemPrompt, combineTemplate, SynthesisPromptData{})
+	prompt.Register(PromptSynthesis, synthesisSystemPrompt, synthesisTemplate, SynthesisPromptData{})
+}
+
+// AnalysisPromptData is what trace analysis templates are rendered with: the
+// analysis itself, plus a span listing fitted to the model's context
+// window. Context is the built-in context sections pre-rendered, for
+// versions that do not lay them out themselves.
+type AnalysisPromptData struct {
+	models.TraceAnalysis
+	Context string
+	Spans   string
+}
+
+// SynthesisPromptData is what the map-reduce combine and synthesis
+// templates are rendered with: the analysis, its context sections as in
+// AnalysisPromptData, the critical path and the partial analyses,
+// pre-rendered.
+type SynthesisPromptData struct {
+	models.TraceAnalysis
+	Context      string
+	CriticalPath string
+	Partials     string
+}
+
+// JudgePromptData is what the judge template is rendered with.
+type JudgePromptData struct {
+	Trace       models.Trace
+	Facts       []models.SymbolicFact
+	Explanation string
+}
+
+// renderPrompt renders a prompt template into a request.
+func renderPrompt(name string, data any) (GenerateRequest, models.PromptRef, error) {
+	system, user, ref, err := prompt.Render(name, data)
+	if err != nil {
+		return GenerateRequest{}, ref, err
+	}
+	req := PromptRequest(user)
+	req.System = system
+	return req, ref, nil
+}
+
+// buildContextPrompt renders a trace analysis template, fitting the span
+// listing into whatever budget the rest of the template leaves and
+// summarizing the trace when it is too large.
+func buildContextPrompt(analysis models.TraceAnalysis, budget int, name string, withIDs bool) (GenerateRequest, models.PromptRef, error) {
+	analysis = fitRetrieved(analysis, int(float64(budget)*retrievedShare))
+
+	data := AnalysisPromptData{TraceAnalysis: analysis, Context: renderContext(analysis)}
+
+	// Render without spans first to measure what the template itself costs.
+	req, _, err := renderPrompt(name, data)
+	if err != nil {
+		return GenerateRequest{}, models.PromptRef{}, err
+	}
+	spanBudget := max(budget-CountTokens(req.System)-CountTokens(req.Messages[0].Content), 0)
+	data.Spans = renderSpans(analysis.Trace, analysis.SymbolicFacts, spanBudget, withIDs)
+
+	return renderPrompt(name, data)
+}
+
+// retrievedShare is the part of the prompt budget similar incidents and
+// runbook sections may take together, leaving the rest for the spans.
+const retrievedShare = 0.25
+
+// fitRetrieved drops the lowest-scoring similar incidents and runbook
+// sections until what they add to the context sections fits budget.
+// Incidents are scored by cosine similarity and runbook sections by an
+// open-ended keyword score, so each is compared relative to the best of
+// its own list.
+func fitRetrieved(analysis models.TraceAnalysis, budget int) models.TraceAnalysis {
+	if len(analysis.SimilarIncidents) == 0 && len(analysis.Runbooks) == 0 {
+		return analysis
+	}
+
+	base := analysis
+	base.SimilarIncidents, base.Runbooks = nil, nil
+	baseTokens := CountTokens(renderContext(base))
+
+	similar := append([]models.SimilarIncident(nil), analysis.SimilarIncidents...)
+	runbooks := append([]models.RunbookSection(nil), analysis.Runbooks...)
+	for len(similar)+len(runbooks) > 0 {
+		analysis.SimilarIncidents, analysis.Runbooks = similar, runbooks
+		if CountTokens(renderContext(analysis))-baseTokens <= budget {
+			break
+		}
+
+		// Both lists are ranked best first; drop the weaker of their tails.
+		switch {
+		case len(runbooks) == 0:
+			similar = similar[:len(similar)-1]
+		case len(similar) == 0:
+			runbooks = runbooks[:len(runbooks)-1]
+		case relativeScore(similar[len(similar)-1].Score, similar[0].Score) < relativeScore(runbooks[len(runbooks)-1].Score, runbooks[0].Score):
+			similar = similar[:len(similar)-1]
+		default:
+			runbooks = runbooks[:len(runbooks)-1]
+		}
+	}
+	analysis.SimilarIncidents, analysis.Runbooks = similar, runbooks
+	return analysis
+}
+
+// relativeScore is score as a fraction of the best score in its list.
+func relativeScore(score, best float64) float64 {
+	if best <= 0 {
+		return 0
+	}
+	return score / best
+}
@@ -8,8 +8,10 @@ import (
 )
 
 type LLMProvider interface {
-	Generate(ctx context.Context, prompt string) (string, error)
-	GenerateStream(ctx context.Context, prompt string, onToken func(string)) error
+	Generate(ctx context.Context, req GenerateRequest) (*GenerateResponse, error)
+	// GenerateStream calls onToken for each token and returns the full
+	// response, with usage, once the stream ends.
+	GenerateStream(ctx context.Context, req GenerateRequest, onToken func(string)) (*GenerateResponse, error)
 }
 
 type ProviderConfig struct {
@@ -66,19 +68,60 @@ func NewEngineFromConnection(conn models.AIConnection) (*Engine, error) {
 		return nil, fmt.Errorf("unsupported provider: %s", conn.Provider)
 	}
 
+	model, _ := conn.Config["model"].(string)
+
 	return &Engine{
 		provider: provider,
 		config: ProviderConfig{
 			Provider: AIProvider(conn.Provider),
-			Model:    conn.Config["model"].(string),
+			Model:    model,
 		},
+		connectionID: conn.ID,
 	}, nil
 }
 
-func (e *Engine) GenerateText(ctx context.Context, prompt string) (string, error) {
-	return e.provider.Generate(ctx, prompt)
+// Config reports the provider and model the engine talks to.
+func (e *Engine) Config() ProviderConfig {
+	return e.config
+}
+
+// ConnectionID identifies the engine in usage reports; the built-in Ollama
+// engine reports as "default".
+func (e *Engine) ConnectionID() string {
+	if e.connectionID == "" {
+		return "default"
+	}
+	return e.connectionID
+}
+
+func (e *Engine) GenerateText(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
+	resp, err := e.provider.Generate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	e.attribute(resp)
+	return resp, nil
+}
+
+func (e *Engine) GenerateTextStream(ctx context.Context, req GenerateRequest, onToken func(string)) (*GenerateResponse, error) {
+	resp, err := e.provider.GenerateStream(ctx, req, onToken)
+	if err != nil {
+		return nil, err
+	}
+	e.attribute(resp)
+	return resp, nil
 }
 
-func (e *Engine) GenerateTextStream(ctx context.Context, prompt string, onToken func(string)) error {
-	return e.provider.GenerateStream(ctx, prompt, onToken)
+// attribute records which provider answered and prices the call. Responses
+// from fallback chains were already attributed by the member that answered.
+func (e *Engine) attribute(resp *GenerateResponse) {
+	if resp.Served != nil {
+		return
+	}
+	resp.Served = &ServedBy{
+		ConnectionID: e.ConnectionID(),
+		Provider:     e.config.Provider,
+		Model:        e.config.Model,
+	}
+	resp.Usage.EstimatedCostUSD = EstimateCost(e.config.Provider, e.config.Model, resp.Usage)
 }
@@ -0,0 +1,121 @@
+package llm
+
+import (
+	"encoding/json"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+	// RoleTool carries the result of a tool call back to the model.
+	RoleTool Role = "tool"
+)
+
+type Message struct {
+	Role    Role   `json:"role"`
+	Content string `json:"content"`
+	// ToolCalls are the calls requested in an assistant message.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID links a RoleTool message to the call it answers.
+	ToolCallID string `json:"tool_call_id,omitempty"`
+}
+
+type ResponseFormat string
+
+const (
+	ResponseFormatText ResponseFormat = ""
+	ResponseFormatJSON ResponseFormat = "json"
+)
+
+// GenerateRequest is a provider-neutral chat request. Zero values mean
+// "use the provider default"; pointer fields distinguish an explicit zero.
+type GenerateRequest struct {
+	System         string
+	Messages       []Message
+	Temperature    *float64
+	MaxTokens      int
+	StopSequences  []string
+	Seed           *int
+	ResponseFormat ResponseFormat
+	// JSONSchema constrains a JSON response. Providers with native structured
+	// output enforce it; others receive it as an instruction.
+	JSONSchema *JSONSchema
+	// Tools are offered to providers with native function calling; see
+	// Engine.RunAgent for the others.
+	Tools []Tool
+	// DisableTools keeps Tools declared, since earlier messages may refer
+	// to them, but forbids the model from calling any.
+	DisableTools bool
+}
+
+type JSONSchema struct {
+	Name   string
+	Schema map[string]any
+}
+
+// PromptRequest wraps a single user prompt with default parameters.
+func PromptRequest(prompt string) GenerateRequest {
+	return GenerateRequest{
+		Messages: []Message{{Role: RoleUser, Content: prompt}},
+	}
+}
+
+func Float(v float64) *float64 { return &v }
+
+func Int(v int) *int { return &v }
+
+// jsonInstruction is appended to the system prompt for providers without a
+// native JSON mode.
+const jsonInstruction = "Respond with a single valid JSON object and nothing else: no prose, no markdown code fences."
+
+func systemWithJSONHint(req GenerateRequest) string {
+	if req.ResponseFormat != ResponseFormatJSON {
+		return req.System
+	}
+	hint := jsonInstruction
+	if req.JSONSchema != nil {
+		hint += "\n\n" + schemaInstruction(req.JSONSchema)
+	}
+	if req.System == "" {
+		return hint
+	}
+	return req.System + "\n\n" + hint
+}
+
+func schemaInstruction(schema *JSONSchema) string {
+	data, _ := json.MarshalIndent(schema.Schema, "", "  ")
+	return "The JSON object must match this JSON Schema:\n" + string(data)
+}
+
+// Usage is the token accounting of a single LLM call.
+type Usage struct {
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	EstimatedCostUSD float64 `json:"estimated_cost_usd"`
+}
+
+// GenerateResponse is the full output of a call; for streams Content is the
+// concatenation of all tokens.
+type GenerateResponse struct {
+	Content   string     `json:"content"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	Usage     Usage      `json:"usage"`
+	// Served is set by Engine to the provider that answered.
+	Served *ServedBy `json:"served_by,omitempty"`
+	// Prompt is the prompt template version the request was rendered from,
+	// when it came from one.
+	Prompt *models.PromptRef `json:"prompt,omitempty"`
+}
+
+func newUsage(prompt, completion int) Usage {
+	return Usage{
+		PromptTokens:     prompt,
+		CompletionTokens: completion,
+		TotalTokens:      prompt + completion,
+	}
+}
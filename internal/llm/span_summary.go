@@ -0,0 +1,301 @@
+package llm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+// renderSpans lists the spans of a trace within a token budget. Traces that
+// fit are listed in full. Larger ones keep the critical path, the error
+// chain and fact-referenced spans in full and are reduced in stages: first
+// repetitive sibling subtrees are collapsed, then everything else is
+// summarized statistically per span name. withIDs prefixes each listed span
+// with its ID so the model can cite it.
+func renderSpans(trace models.Trace, facts []models.SymbolicFact, budget int, withIDs bool) string {
+	tree := newSpanTree(trace)
+	tree.withIDs = withIDs
+
+	var full strings.Builder
+	for i := range trace.Spans {
+		full.WriteString(tree.line(i) + "\n")
+	}
+	if CountTokens(full.String()) <= budget {
+		return full.String()
+	}
+
+	keep := tree.importantSpans(facts)
+
+	var collapsed strings.Builder
+	collapsed.WriteString(fmt.Sprintf("(%d spans; critical path, error chain and fact-referenced spans shown in full, repetitive subtrees collapsed)\n", len(trace.Spans)))
+	for _, root := range tree.roots {
+		tree.renderCollapsed(&collapsed, root, 0, keep)
+	}
+	if CountTokens(collapsed.String()) <= budget {
+		return collapsed.String()
+	}
+
+	return tree.renderStatistical(keep, budget)
+}
+
+func spanLine(s *models.Span) string {
+	status := s.Status.Code
+	if s.Status.Message != "" {
+		status += fmt.Sprintf(" (%s)", s.Status.Message)
+	}
+	return fmt.Sprintf("- %s: %s [%.2fms]", s.Name, status, s.LatencyMs())
+}
+
+func (t *spanTree) line(i int) string {
+	if t.withIDs {
+		return fmt.Sprintf("- (%s) %s", t.spans[i].SpanID, spanLine(&t.spans[i])[2:])
+	}
+	return spanLine(&t.spans[i])
+}
+
+type spanTree struct {
+	spans    []models.Span
+	parent   []int
+	children [][]int
+	roots    []int
+	withIDs  bool
+}
+
+func newSpanTree(trace models.Trace) *spanTree {
+	t := &spanTree{
+		spans:    trace.Spans,
+		parent:   make([]int, len(trace.Spans)),
+		children: make([][]int, len(trace.Spans)),
+	}
+
+	byID := make(map[string]int, len(trace.Spans))
+	for i, s := range trace.Spans {
+		byID[s.SpanID] = i
+	}
+	for i, s := range trace.Spans {
+		p, ok := byID[s.ParentSpanID]
+		if s.ParentSpanID == "" || !ok || p == i {
+			t.parent[i] = -1
+			t.roots = append(t.roots, i)
+			continue
+		}
+		t.parent[i] = p
+		t.children[p] = append(t.children[p], i)
+	}
+
+	byStart := func(ids []int) {
+		sort.SliceStable(ids, func(a, b int) bool {
+			return t.spans[ids[a]].StartTime.Before(t.spans[ids[b]].StartTime)
+		})
+	}
+	byStart(t.roots)
+	for i := range t.children {
+		byStart(t.children[i])
+	}
+	return t
+}
+
+// importantSpans marks the critical path, every error span, the slowest span
+// of each fact's service, and all of their ancestors.
+func (t *spanTree) importantSpans(facts []models.SymbolicFact) map[int]bool {
+	keep := make(map[int]bool)
+	mark := func(i int) {
+		for ; i >= 0 && !keep[i]; i = t.parent[i] {
+			keep[i] = true
+		}
+	}
+
+	for _, i := range t.criticalPath() {
+		mark(i)
+	}
+
+	for i := range t.spans {
+		if t.spans[i].Status.Code == "ERROR" {
+			mark(i)
+		}
+	}
+
+	slowest := make(map[string]int)
+	for _, f := range facts {
+		slowest[f.Service] = -1
+	}
+	for i := range t.spans {
+		best, ok := slowest[t.spans[i].Name]
+		if ok && (best < 0 || t.spans[i].LatencyMs() > t.spans[best].LatencyMs()) {
+			slowest[t.spans[i].Name] = i
+		}
+	}
+	for _, i := range slowest {
+		if i >= 0 {
+			mark(i)
+		}
+	}
+
+	return keep
+}
+
+// criticalPath follows, from the longest root, the child that finishes last
+// at each level, since it is the one holding up its parent.
+func (t *spanTree) criticalPath() []int {
+	if len(t.roots) == 0 {
+		return nil
+	}
+
+	current := t.roots[0]
+	for _, r := range t.roots[1:] {
+		if t.spans[r].LatencyMs() > t.spans[current].LatencyMs() {
+			current = r
+		}
+	}
+
+	path := []int{current}
+	for len(t.children[current]) > 0 {
+		next := t.children[current][0]
+		for _, c := range t.children[current][1:] {
+			if t.spans[c].EndTime.After(t.spans[next].EndTime) {
+				next = c
+			}
+		}
+		path = append(path, next)
+		current = next
+	}
+	return path
+}
+
+func (t *spanTree) descendants(i int) int {
+	n := 0
+	for _, c := range t.children[i] {
+		n += 1 + t.descendants(c)
+	}
+	return n
+}
+
+// renderCollapsed writes kept spans in full and folds their other children
+// into one line per span name and status.
+func (t *spanTree) renderCollapsed(sb *strings.Builder, i, depth int, keep map[int]bool) {
+	indent := strings.Repeat("  ", depth)
+	sb.WriteString(indent + t.line(i) + "\n")
+
+	type group struct {
+		name, status string
+		count        int
+		nested       int
+		totalMs      float64
+		maxMs        float64
+	}
+	var groups []*group
+	index := make(map[string]*group)
+
+	for _, c := range t.children[i] {
+		if keep[c] {
+			t.renderCollapsed(sb, c, depth+1, keep)
+			continue
+		}
+
+		s := &t.spans[c]
+		key := s.Name + "\x00" + s.Status.Code
+		g, ok := index[key]
+		if !ok {
+			g = &group{name: s.Name, status: s.Status.Code}
+			index[key] = g
+			groups = append(groups, g)
+		}
+		g.count++
+		g.nested += t.descendants(c)
+		g.totalMs += s.LatencyMs()
+		if s.LatencyMs() > g.maxMs {
+			g.maxMs = s.LatencyMs()
+		}
+	}
+
+	for _, g := range groups {
+		line := fmt.Sprintf("%s  - %d× %s: %s [avg %.2fms, max %.2fms]", indent, g.count, g.name, g.status, g.totalMs/float64(g.count), g.maxMs)
+		if g.nested > 0 {
+			line += fmt.Sprintf(" (+%d nested spans)", g.nested)
+		}
+		sb.WriteString(line + "\n")
+	}
+}
+
+// renderStatistical lists only kept spans, then per-name statistics of the
+// rest, slowest in total first, for as long as the budget allows.
+func (t *spanTree) renderStatistical(keep map[int]bool, budget int) string {
+	var sb strings.Builder
+	used := 0
+	write := func(line string) bool {
+		tokens := CountTokens(line)
+		if used+tokens > budget {
+			return false
+		}
+		sb.WriteString(line)
+		used += tokens
+		return true
+	}
+
+	write(fmt.Sprintf("(%d spans; only critical path, error chain and fact-referenced spans are listed, the rest is summarized by name)\n", len(t.spans)))
+
+	var keptLines []string
+	var walk func(i, depth int)
+	walk = func(i, depth int) {
+		keptLines = append(keptLines, strings.Repeat("  ", depth)+t.line(i)+"\n")
+		for _, c := range t.children[i] {
+			if keep[c] {
+				walk(c, depth+1)
+			}
+		}
+	}
+	for _, r := range t.roots {
+		if keep[r] {
+			walk(r, 0)
+		}
+	}
+	for n, line := range keptLines {
+		if !write(line) {
+			sb.WriteString(fmt.Sprintf("- ... %d more spans omitted to fit the context window\n", len(keptLines)-n))
+			return sb.String()
+		}
+	}
+
+	type stats struct {
+		name           string
+		count          int
+		totalMs, maxMs float64
+	}
+	index := make(map[string]*stats)
+	var all []*stats
+	for i := range t.spans {
+		if keep[i] {
+			continue
+		}
+		s := &t.spans[i]
+		st, ok := index[s.Name]
+		if !ok {
+			st = &stats{name: s.Name}
+			index[s.Name] = st
+			all = append(all, st)
+		}
+		st.count++
+		st.totalMs += s.LatencyMs()
+		if s.LatencyMs() > st.maxMs {
+			st.maxMs = s.LatencyMs()
+		}
+	}
+	if len(all) == 0 {
+		return sb.String()
+	}
+	sort.SliceStable(all, func(a, b int) bool { return all[a].totalMs > all[b].totalMs })
+
+	if !write("Other spans:\n") {
+		return sb.String()
+	}
+	for n, st := range all {
+		line := fmt.Sprintf("- %s: %d spans, avg %.2fms, max %.2fms, total %.2fms\n", st.name, st.count, st.totalMs/float64(st.count), st.maxMs, st.totalMs)
+		if !write(line) {
+			sb.WriteString(fmt.Sprintf("- ... %d more span names omitted\n", len(all)-n))
+			break
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,155 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+// maxStructuredAttempts is the first attempt plus repair retries.
+const maxStructuredAttempts = 3
+
+// explanationSchema mirrors models.StructuredExplanation. It stays within the
+// subset accepted by both OpenAI strict mode and Anthropic structured
+// outputs: every property required, no additional properties, no numeric bounds.
+var explanationSchema = &JSONSchema{
+	Name: "trace_explanation",
+	Schema: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"root_cause": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"service":     map[string]any{"type": "string", "description": "Service where the failure or slowdown originates."},
+					"description": map[string]any{"type": "string", "description": "What went wrong and how it propagated."},
+				},
+				"required":             []string{"service", "description"},
+				"additionalProperties": false,
+			},
+			"affected_services": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"is_systemic":       map[string]any{"type": "boolean", "description": "True when this is part of a system-wide trend rather than an isolated incident."},
+			"confidence":        map[string]any{"type": "number", "description": "Confidence in the root cause, from 0 to 1."},
+			"remediation":       map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Remediation steps, highest priority first."},
+			"evidence_span_ids": map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "IDs of the spans that support the root cause."},
+		},
+		"required":             []string{"root_cause", "affected_services", "is_systemic", "confidence", "remediation", "evidence_span_ids"},
+		"additionalProperties": false,
+	},
+}
+
+const jsonAnalystSystemPrompt = analystSystemPrompt + `
+
+Report your analysis as JSON. Span IDs are shown in parentheses before each span name; cite them in evidence_span_ids.`
+
+// ExplainTraceJSON asks for an explanation matching explanationSchema,
+// streaming tokens as they arrive. Output that fails validation is sent back
+// with the problems found, up to maxStructuredAttempts in total; onRepair is
+// called before each retry.
+func (e *Engine) ExplainTraceJSON(ctx context.Context, analysis models.TraceAnalysis, onToken func(string), onRepair func(attempt int, problems []string)) (*models.StructuredExplanation, *GenerateResponse, error) {
+	req, ref, err := buildContextPrompt(analysis, promptBudget(e.config, GenerateRequest{}), PromptAnalysisJSON, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.ResponseFormat = ResponseFormatJSON
+	req.JSONSchema = explanationSchema
+
+	var usage Usage
+	var problems []string
+	for attempt := 1; attempt <= maxStructuredAttempts; attempt++ {
+		if attempt > 1 {
+			onRepair(attempt, problems)
+		}
+
+		resp, err := e.GenerateTextStream(ctx, req, onToken)
+		if err != nil {
+			return nil, nil, err
+		}
+		usage = addUsage(usage, resp.Usage)
+
+		var explanation *models.StructuredExplanation
+		explanation, problems = parseStructuredExplanation(resp.Content, analysis.Trace)
+		if len(problems) == 0 {
+			resp.Usage = usage
+			resp.Prompt = &ref
+			return explanation, resp, nil
+		}
+
+		req.Messages = append(req.Messages,
+			Message{Role: RoleAssistant, Content: resp.Content},
+			Message{Role: RoleUser, Content: "Your response did not match the required JSON schema:\n- " + strings.Join(problems, "\n- ") + "\n\nReturn the corrected JSON object only."},
+		)
+	}
+
+	return nil, nil, fmt.Errorf("no valid structured explanation after %d attempts: %s", maxStructuredAttempts, strings.Join(problems, "; "))
+}
+
+// parseStructuredExplanation decodes and validates model output, returning
+// every problem found so one repair round can fix them all.
+func parseStructuredExplanation(content string, trace models.Trace) (*models.StructuredExplanation, []string) {
+	data := []byte(extractJSON(content))
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, []string{fmt.Sprintf("response is not a JSON object: %v", err)}
+	}
+
+	var problems []string
+	for _, key := range explanationSchema.Schema["required"].([]string) {
+		if _, ok := fields[key]; !ok {
+			problems = append(problems, fmt.Sprintf("missing required field %q", key))
+		}
+	}
+
+	var explanation models.StructuredExplanation
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&explanation); err != nil {
+		return nil, append(problems, fmt.Sprintf("invalid field: %v", err))
+	}
+
+	if explanation.RootCause.Service == "" {
+		problems = append(problems, "root_cause.service must not be empty")
+	}
+	if explanation.RootCause.Description == "" {
+		problems = append(problems, "root_cause.description must not be empty")
+	}
+	if len(explanation.AffectedServices) == 0 {
+		problems = append(problems, "affected_services must list at least one service")
+	}
+	if explanation.Confidence < 0 || explanation.Confidence > 1 {
+		problems = append(problems, fmt.Sprintf("confidence must be between 0 and 1, got %v", explanation.Confidence))
+	}
+	if len(explanation.Remediation) == 0 {
+		problems = append(problems, "remediation must list at least one step")
+	}
+
+	spanIDs := make(map[string]bool, len(trace.Spans))
+	for _, s := range trace.Spans {
+		spanIDs[s.SpanID] = true
+	}
+	for _, id := range explanation.EvidenceSpanIDs {
+		if !spanIDs[id] {
+			problems = append(problems, fmt.Sprintf("evidence_span_ids contains %q, which is not a span of this trace", id))
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, problems
+	}
+	return &explanation, nil
+}
+
+// extractJSON strips markdown fences and any prose around the outermost object.
+func extractJSON(content string) string {
+	content = strings.TrimSpace(content)
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return content
+	}
+	return content[start : end+1]
+}
@@ -0,0 +1,93 @@
+package llm
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tokenizerTimeout bounds downloading an encoding, after which token
+// counts fall back to an approximation.
+const tokenizerTimeout = 10 * time.Second
+
+// bpeLoader loads tiktoken encodings like tiktoken's default loader,
+// sharing its cache, but downloads with a client that times out.
+type bpeLoader struct {
+	client *http.Client
+}
+
+func (l *bpeLoader) LoadTiktokenBpe(url string) (map[string]int, error) {
+	data, err := l.read(url)
+	if err != nil {
+		return nil, err
+	}
+
+	ranks := make(map[string]int)
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		token, rank, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("malformed encoding line %q", line)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(token)
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.Atoi(rank)
+		if err != nil {
+			return nil, err
+		}
+		ranks[string(decoded)] = n
+	}
+	return ranks, nil
+}
+
+// read returns the encoding file from the cache, downloading and caching
+// it when missing.
+func (l *bpeLoader) read(url string) ([]byte, error) {
+	cachePath := filepath.Join(tiktokenCacheDir(), fmt.Sprintf("%x", sha1.Sum([]byte(url))))
+	if data, err := os.ReadFile(cachePath); err == nil {
+		return data, nil
+	}
+
+	resp, err := l.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download encoding: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download encoding: %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download encoding: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err == nil {
+		tmp := cachePath + ".tmp"
+		if err := os.WriteFile(tmp, data, 0o644); err == nil {
+			os.Rename(tmp, cachePath)
+		}
+	}
+	return data, nil
+}
+
+// tiktokenCacheDir is where tiktoken caches encodings.
+func tiktokenCacheDir() string {
+	if dir := os.Getenv("TIKTOKEN_CACHE_DIR"); dir != "" {
+		return dir
+	}
+	if dir := os.Getenv("DATA_GYM_CACHE_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.TempDir(), "data-gym-cache")
+}
@@ -0,0 +1,79 @@
+package llm
+
+import "sync"
+
+// UsageTotals aggregates usage over many calls.
+type UsageTotals struct {
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	EstimatedCostUSD float64 `json:"estimated_cost_usd"`
+}
+
+func (t *UsageTotals) add(u Usage) {
+	t.Calls++
+	t.PromptTokens += u.PromptTokens
+	t.CompletionTokens += u.CompletionTokens
+	t.TotalTokens += u.TotalTokens
+	t.EstimatedCostUSD += u.EstimatedCostUSD
+}
+
+type UsageReport struct {
+	Total        UsageTotals            `json:"total"`
+	ByConnection map[string]UsageTotals `json:"by_connection"`
+	ByEndpoint   map[string]UsageTotals `json:"by_endpoint"`
+}
+
+// UsageTracker accumulates usage per connection and per endpoint. A nil
+// tracker ignores records.
+type UsageTracker struct {
+	mu           sync.Mutex
+	total        UsageTotals
+	byConnection map[string]*UsageTotals
+	byEndpoint   map[string]*UsageTotals
+}
+
+func NewUsageTracker() *UsageTracker {
+	return &UsageTracker{
+		byConnection: make(map[string]*UsageTotals),
+		byEndpoint:   make(map[string]*UsageTotals),
+	}
+}
+
+func (t *UsageTracker) Record(connectionID, endpoint string, u Usage) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.total.add(u)
+	if _, ok := t.byConnection[connectionID]; !ok {
+		t.byConnection[connectionID] = &UsageTotals{}
+	}
+	t.byConnection[connectionID].add(u)
+	if _, ok := t.byEndpoint[endpoint]; !ok {
+		t.byEndpoint[endpoint] = &UsageTotals{}
+	}
+	t.byEndpoint[endpoint].add(u)
+}
+
+func (t *UsageTracker) Report() UsageReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	report := UsageReport{
+		Total:        t.total,
+		ByConnection: make(map[string]UsageTotals, len(t.byConnection)),
+		ByEndpoint:   make(map[string]UsageTotals, len(t.byEndpoint)),
+	}
+	for k, v := range t.byConnection {
+		report.ByConnection[k] = *v
+	}
+	for k, v := range t.byEndpoint {
+		report.ByEndpoint[k] = *v
+	}
+	return report
+}
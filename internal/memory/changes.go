@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+// AddChange records a change event, keeping the most recent ones by timestamp.
+func (s *Store) AddChange(change models.ChangeEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.changes = append(s.changes, change)
+	sort.SliceStable(s.changes, func(i, j int) bool {
+		return s.changes[i].Timestamp.Before(s.changes[j].Timestamp)
+	})
+	if len(s.changes) > s.maxChanges {
+		s.changes = s.changes[len(s.changes)-s.maxChanges:]
+	}
+}
+
+// Changes returns change events newest first, optionally filtered by service
+// and by a lower time bound.
+func (s *Store) Changes(service string, since time.Time) []models.ChangeEvent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	changes := make([]models.ChangeEvent, 0)
+	for i := len(s.changes) - 1; i >= 0; i-- {
+		c := s.changes[i]
+		if c.Timestamp.Before(since) {
+			break
+		}
+		if service != "" && c.Service != service {
+			continue
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
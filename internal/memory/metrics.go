@@ -0,0 +1,160 @@
+package memory
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+// maxPointsPerSeries caps memory use for high-frequency series.
+const maxPointsPerSeries = 2000
+
+// maxMetricSkew is how far ahead of the local clock a sample may be
+// timestamped; later ones are taken to be from now, so that one bad
+// clock cannot make every other series look stale.
+const maxMetricSkew = time.Minute
+
+// SetMetricRetention changes how far back metric points are kept.
+func (s *Store) SetMetricRetention(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metricRetention = d
+}
+
+// SetMaxMetricSeries caps the number of series kept; when more arrive, the
+// series that were updated least recently are dropped. Zero removes the cap.
+func (s *Store) SetMaxMetricSeries(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxMetricSeries = n
+	s.evictMetricSeries()
+}
+
+// AddMetricSamples appends samples to their series, dropping points older
+// than the retention window relative to each series' newest point. Series
+// that have not been updated within the retention window of the newest
+// sample seen are dropped entirely. Samples from more than maxMetricSkew
+// in the future are recorded at the current time.
+func (s *Store) AddMetricSamples(samples []models.MetricSample) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	touched := make(map[string]*models.MetricSeries)
+	for _, sample := range samples {
+		if sample.Timestamp.After(now.Add(maxMetricSkew)) {
+			sample.Timestamp = now
+		}
+		key := seriesKey(sample.Service, sample.Name, sample.Labels)
+		series, ok := s.metricSeries[key]
+		if !ok {
+			series = &models.MetricSeries{
+				Service: sample.Service,
+				Name:    sample.Name,
+				Labels:  sample.Labels,
+			}
+			s.metricSeries[key] = series
+		}
+		series.Points = append(series.Points, models.MetricPoint{Timestamp: sample.Timestamp, Value: sample.Value})
+		touched[key] = series
+		if sample.Timestamp.After(s.metricNewest) {
+			s.metricNewest = sample.Timestamp
+		}
+	}
+
+	for _, series := range touched {
+		sort.SliceStable(series.Points, func(i, j int) bool {
+			return series.Points[i].Timestamp.Before(series.Points[j].Timestamp)
+		})
+
+		cutoff := series.Points[len(series.Points)-1].Timestamp.Add(-s.metricRetention)
+		first := sort.Search(len(series.Points), func(i int) bool {
+			return !series.Points[i].Timestamp.Before(cutoff)
+		})
+		if len(series.Points)-first > maxPointsPerSeries {
+			first = len(series.Points) - maxPointsPerSeries
+		}
+		series.Points = append([]models.MetricPoint(nil), series.Points[first:]...)
+	}
+
+	s.evictMetricSeries()
+}
+
+// evictMetricSeries drops stale series and then, over the cap, the least
+// recently updated ones. The caller must hold s.mu.
+func (s *Store) evictMetricSeries() {
+	stale := s.metricNewest.Add(-s.metricRetention)
+	for key, series := range s.metricSeries {
+		if lastUpdate(series).Before(stale) {
+			delete(s.metricSeries, key)
+		}
+	}
+
+	excess := len(s.metricSeries) - s.maxMetricSeries
+	if s.maxMetricSeries <= 0 || excess <= 0 {
+		return
+	}
+	keys := make([]string, 0, len(s.metricSeries))
+	for key := range s.metricSeries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return lastUpdate(s.metricSeries[keys[i]]).Before(lastUpdate(s.metricSeries[keys[j]]))
+	})
+	for _, key := range keys[:excess] {
+		delete(s.metricSeries, key)
+	}
+}
+
+func lastUpdate(series *models.MetricSeries) time.Time {
+	if len(series.Points) == 0 {
+		return time.Time{}
+	}
+	return series.Points[len(series.Points)-1].Timestamp
+}
+
+// MetricSeries returns copies of the stored series, optionally restricted to services.
+func (s *Store) MetricSeries(services ...string) []models.MetricSeries {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(services))
+	for _, svc := range services {
+		wanted[svc] = true
+	}
+
+	result := make([]models.MetricSeries, 0)
+	for _, series := range s.metricSeries {
+		if len(wanted) > 0 && !wanted[series.Service] {
+			continue
+		}
+		cp := *series
+		cp.Points = append([]models.MetricPoint(nil), series.Points...)
+		result = append(result, cp)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Service != result[j].Service {
+			return result[i].Service < result[j].Service
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+func seriesKey(service, name string, labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString(service + "|" + name)
+	for _, k := range keys {
+		sb.WriteString("|" + k + "=" + labels[k])
+	}
+	return sb.String()
+}
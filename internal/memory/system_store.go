@@ -12,12 +12,26 @@ type Store struct {
 	mu           sync.RWMutex
 	recentTraces []models.Trace
 	maxTraces    int
+
+	metricSeries    map[string]*models.MetricSeries
+	metricRetention time.Duration
+	maxMetricSeries int
+	// metricNewest is the newest sample timestamp seen, against which
+	// series go stale.
+	metricNewest time.Time
+
+	changes    []models.ChangeEvent
+	maxChanges int
 }
 
 func NewStore(maxItems int) *Store {
 	return &Store{
-		recentTraces: make([]models.Trace, 0),
-		maxTraces:    maxItems,
+		recentTraces:    make([]models.Trace, 0),
+		maxTraces:       maxItems,
+		metricSeries:    make(map[string]*models.MetricSeries),
+		metricRetention: time.Hour,
+		maxMetricSeries: 10000,
+		maxChanges:      500,
 	}
 }
 
@@ -73,3 +87,66 @@ func (s *Store) GetHealth() models.SystemHealth {
 
 	return health
 }
+
+// RecentTraces returns a snapshot of the traces currently held in memory.
+func (s *Store) RecentTraces() []models.Trace {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	traces := make([]models.Trace, len(s.recentTraces))
+	copy(traces, s.recentTraces)
+	return traces
+}
+
+// GetServiceStats aggregates span counts, errors and latencies per service.
+func (s *Store) GetServiceStats() map[string]models.ServiceStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := make(map[string]models.ServiceStats)
+	for _, t := range s.recentTraces {
+		for _, span := range t.Spans {
+			st := stats[span.Name]
+			latency := span.LatencyMs()
+			st.AvgLatencyMs = (st.AvgLatencyMs*float64(st.Spans) + latency) / float64(st.Spans+1)
+			st.Spans++
+			if span.Status.Code == "ERROR" {
+				st.Errors++
+			}
+			if latency > st.MaxLatencyMs {
+				st.MaxLatencyMs = latency
+			}
+			stats[span.Name] = st
+		}
+	}
+
+	return stats
+}
+
+// FindTraces returns the traces that touch service and overlap [from, to].
+// A span matches the service by name or by its "service.name" attribute; an
+// empty service matches every trace.
+func (s *Store) FindTraces(service string, from, to time.Time) []models.Trace {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matches []models.Trace
+	for _, t := range s.recentTraces {
+		var inService, inWindow bool
+		for i := range t.Spans {
+			span := &t.Spans[i]
+			if service == "" || span.Name == service {
+				inService = true
+			} else if v, ok := span.Attribute("service.name"); ok && v == service {
+				inService = true
+			}
+			if !span.EndTime.Before(from) && !span.StartTime.After(to) {
+				inWindow = true
+			}
+		}
+		if inService && inWindow {
+			matches = append(matches, t)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// AlertmanagerWebhook is the payload Prometheus Alertmanager posts to webhook receivers.
+type AlertmanagerWebhook struct {
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	Status            string            `json:"status"`
+	Receiver          string            `json:"receiver"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Alerts            []Alert           `json:"alerts"`
+}
+
+type Alert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// AlertAnalysis is the explanation TraceMind produced for an alert, keyed by fingerprint.
+type AlertAnalysis struct {
+	Fingerprint string            `json:"fingerprint"`
+	AlertName   string            `json:"alert_name"`
+	Status      string            `json:"status"`
+	Service     string            `json:"service"`
+	Labels      map[string]string `json:"labels"`
+	WindowStart time.Time         `json:"window_start"`
+	WindowEnd   time.Time         `json:"window_end"`
+	TraceIDs    []string          `json:"trace_ids"`
+	Facts       []SymbolicFact    `json:"facts"`
+	Explanation string            `json:"explanation"`
+	Prompt      *PromptRef        `json:"prompt,omitempty"`
+	Error       string            `json:"error,omitempty"`
+	ReceivedAt  time.Time         `json:"received_at"`
+	AnalyzedAt  *time.Time        `json:"analyzed_at,omitempty"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ChangeEvent is a deployment, config or feature-flag change to a service.
+type ChangeEvent struct {
+	ID          string    `json:"id"`
+	Service     string    `json:"service"`
+	Kind        string    `json:"kind"`
+	Version     string    `json:"version,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Author      string    `json:"author,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+}
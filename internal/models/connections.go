@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 )
@@ -11,6 +12,8 @@ const (
 	ProviderOpenAI    AIProvider = "openai"
 	ProviderAnthropic AIProvider = "anthropic"
 	ProviderOllama    AIProvider = "ollama"
+	// ProviderFallback chains other connections, trying them in order.
+	ProviderFallback AIProvider = "fallback"
 )
 
 type AIConnection struct {
@@ -39,6 +42,19 @@ type OllamaConfig struct {
 	Model    string `json:"model"`
 }
 
+// FallbackConfig lists the connections of a fallback chain in the order
+// they are tried. Durations use Go syntax, e.g. "30s".
+type FallbackConfig struct {
+	Connections    []FallbackMemberConfig `json:"connections"`
+	MaxRetries     int                    `json:"max_retries"`
+	InitialBackoff string                 `json:"initial_backoff"`
+}
+
+type FallbackMemberConfig struct {
+	ConnectionID string `json:"connection_id"`
+	Timeout      string `json:"timeout"`
+}
+
 func (c *AIConnection) Validate() error {
 	if c.Name == "" {
 		return fmt.Errorf("connection name is required")
@@ -54,6 +70,8 @@ func (c *AIConnection) Validate() error {
 		return c.validateAnthropic()
 	case ProviderOllama:
 		return c.validateOllama()
+	case ProviderFallback:
+		return c.validateFallback()
 	default:
 		return fmt.Errorf("unsupported provider: %s", c.Provider)
 	}
@@ -101,6 +119,36 @@ func (c *AIConnection) validateOllama() error {
 	return nil
 }
 
+func (c *AIConnection) validateFallback() error {
+	config, err := c.GetFallbackConfig()
+	if err != nil {
+		return err
+	}
+	if len(config.Connections) == 0 {
+		return fmt.Errorf("fallback connections are required")
+	}
+	for _, m := range config.Connections {
+		if m.ConnectionID == "" {
+			return fmt.Errorf("fallback connection_id is required")
+		}
+		if m.Timeout != "" {
+			if _, err := time.ParseDuration(m.Timeout); err != nil {
+				return fmt.Errorf("invalid fallback timeout %q: %w", m.Timeout, err)
+			}
+		}
+	}
+	if config.MaxRetries < 0 {
+		return fmt.Errorf("fallback max_retries must not be negative")
+	}
+	if config.InitialBackoff != "" {
+		if _, err := time.ParseDuration(config.InitialBackoff); err != nil {
+			return fmt.Errorf("invalid fallback initial_backoff %q: %w", config.InitialBackoff, err)
+		}
+	}
+
+	return nil
+}
+
 func (c *AIConnection) GetOpenAIConfig() (*OpenAIConfig, error) {
 	if c.Provider != ProviderOpenAI {
 		return nil, fmt.Errorf("connection is not OpenAI provider")
@@ -148,3 +196,20 @@ func (c *AIConnection) GetOllamaConfig() (*OllamaConfig, error) {
 		Model:    model,
 	}, nil
 }
+
+func (c *AIConnection) GetFallbackConfig() (*FallbackConfig, error) {
+	if c.Provider != ProviderFallback {
+		return nil, fmt.Errorf("connection is not a fallback chain")
+	}
+
+	// Config arrives as decoded JSON; round-trip it into the typed form.
+	data, err := json.Marshal(c.Config)
+	if err != nil {
+		return nil, err
+	}
+	var config FallbackConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid fallback config: %w", err)
+	}
+	return &config, nil
+}
@@ -0,0 +1,45 @@
+package models
+
+// StructuredExplanation is the machine-readable form of a trace explanation,
+// suitable for ticketing and automation.
+type StructuredExplanation struct {
+	RootCause        RootCause `json:"root_cause"`
+	AffectedServices []string  `json:"affected_services"`
+	IsSystemic       bool      `json:"is_systemic"`
+	// Confidence is between 0 and 1.
+	Confidence      float64  `json:"confidence"`
+	Remediation     []string `json:"remediation"`
+	EvidenceSpanIDs []string `json:"evidence_span_ids"`
+}
+
+type RootCause struct {
+	Service     string `json:"service"`
+	Description string `json:"description"`
+}
+
+// JudgeVerdict is one judge pass over an explanation. Scores are from 1 to 10.
+type JudgeVerdict struct {
+	// Score rates causal correctness.
+	Score          float64       `json:"score"`
+	Rationale      string        `json:"rationale"`
+	RootCauseFound bool          `json:"root_cause_found"`
+	Criteria       JudgeCriteria `json:"criteria"`
+}
+
+type JudgeCriteria struct {
+	Completeness  float64 `json:"completeness"`
+	Actionability float64 `json:"actionability"`
+	// Hallucination is 10 when every claim is supported by the trace or
+	// facts, and lower for each unsupported one.
+	Hallucination float64 `json:"hallucination"`
+}
+
+// Evaluation aggregates the judge passes over an explanation: scores are
+// averaged, root_cause_found is the majority verdict, and the rationale is
+// that of the pass closest to the mean score.
+type Evaluation struct {
+	JudgeVerdict
+	// ScoreStdDev is the spread of scores across passes.
+	ScoreStdDev float64        `json:"score_stddev"`
+	Passes      []JudgeVerdict `json:"passes"`
+}
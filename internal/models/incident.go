@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+type IncidentStatus string
+
+const (
+	IncidentOpen     IncidentStatus = "open"
+	IncidentResolved IncidentStatus = "resolved"
+)
+
+// Incident is opened by the detector when memory aggregates breach a threshold.
+type Incident struct {
+	ID            string         `json:"id"`
+	Status        IncidentStatus `json:"status"`
+	Trigger       string         `json:"trigger"`
+	Service       string         `json:"service,omitempty"`
+	Description   string         `json:"description"`
+	Severity      string         `json:"severity"`
+	ObservedValue float64        `json:"observed_value"`
+	Threshold     float64        `json:"threshold"`
+	TraceIDs      []string       `json:"trace_ids"`
+	Facts         []SymbolicFact `json:"facts"`
+	Summary       string         `json:"summary"`
+	Prompt        *PromptRef     `json:"prompt,omitempty"`
+	OpenedAt      time.Time      `json:"opened_at"`
+	ResolvedAt    *time.Time     `json:"resolved_at,omitempty"`
+}
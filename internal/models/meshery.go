@@ -21,9 +21,11 @@ type Component struct {
 }
 
 type DesignMeta struct {
-	GeneratedBy string    `json:"generated_by"`
-	Prompt      string    `json:"prompt"`
-	Provider    string    `json:"provider"`
-	Model       string    `json:"model"`
-	GeneratedAt time.Time `json:"generated_at"`
+	GeneratedBy string `json:"generated_by"`
+	Prompt      string `json:"prompt"`
+	// Template is the prompt template version the design was generated from.
+	Template    *PromptRef `json:"template,omitempty"`
+	Provider    string     `json:"provider"`
+	Model       string     `json:"model"`
+	GeneratedAt time.Time  `json:"generated_at"`
 }
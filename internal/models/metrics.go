@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// MetricSample is a single ingested data point.
+type MetricSample struct {
+	Service   string            `json:"service"`
+	Name      string            `json:"name"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+	Value     float64           `json:"value"`
+}
+
+type MetricPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// MetricSeries is the recent history of one metric/label set for a service.
+type MetricSeries struct {
+	Service string            `json:"service"`
+	Name    string            `json:"name"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Points  []MetricPoint     `json:"points"`
+}
+
+// MetricAnomaly is a metric that misbehaved during a trace's time window.
+type MetricAnomaly struct {
+	Service      string    `json:"service"`
+	Metric       string    `json:"metric"`
+	Peak         float64   `json:"peak"`
+	BaselineMean float64   `json:"baseline_mean"`
+	ZScore       float64   `json:"z_score,omitempty"`
+	At           time.Time `json:"at"`
+	Description  string    `json:"description"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// SessionMessage is one turn of a follow-up conversation about a trace.
+type SessionMessage struct {
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+	Timestamp time.Time `json:"timestamp"`
+	// Prompt is the template version behind an assistant turn.
+	Prompt *PromptRef `json:"prompt,omitempty"`
+}
+
+// Session keeps an analyzed trace and the conversation about it.
+type Session struct {
+	ID           string           `json:"id"`
+	TraceID      string           `json:"trace_id"`
+	ConnectionID string           `json:"connection_id,omitempty"`
+	Analysis     TraceAnalysis    `json:"analysis"`
+	Messages     []SessionMessage `json:"messages"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+	ExpiresAt    time.Time        `json:"expires_at"`
+}
@@ -32,12 +32,36 @@ func (s *Span) LatencyMs() float64 {
 	return float64(s.EndTime.Sub(s.StartTime).Microseconds()) / 1000.0
 }
 
+// Attribute returns the value of the named attribute, if present.
+func (s *Span) Attribute(key string) (interface{}, bool) {
+	for _, a := range s.Attributes {
+		if a.Key == key {
+			return a.Value, true
+		}
+	}
+	return nil, false
+}
+
 // Trace represents a collection of OTel spans.
 type Trace struct {
 	TraceID string `json:"trace_id"`
 	Spans   []Span `json:"spans"`
 }
 
+// TimeWindow returns the earliest span start and the latest span end.
+func (t *Trace) TimeWindow() (time.Time, time.Time) {
+	var start, end time.Time
+	for i, s := range t.Spans {
+		if i == 0 || s.StartTime.Before(start) {
+			start = s.StartTime
+		}
+		if i == 0 || s.EndTime.After(end) {
+			end = s.EndTime
+		}
+	}
+	return start, end
+}
+
 // SymbolicFact represents a pre-computed insight about the trace.
 type SymbolicFact struct {
 	Type        string `json:"type"`
@@ -53,10 +77,49 @@ type SystemHealth struct {
 	LastUpdate      time.Time `json:"last_update"`
 }
 
+// ServiceStats is the per-service aggregate kept by symbolic memory.
+type ServiceStats struct {
+	Spans        int     `json:"spans"`
+	Errors       int     `json:"errors"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	MaxLatencyMs float64 `json:"max_latency_ms"`
+}
+
 // TraceAnalysis combines raw data with symbolic reasoning and AI streaming output.
 type TraceAnalysis struct {
 	Trace         Trace          `json:"trace"`
 	SymbolicFacts []SymbolicFact `json:"symbolic_facts"`
 	SystemContext SystemHealth   `json:"system_context"`
-	AIExplanation string         `json:"ai_explanation"` // For unary responses
+	// MetricAnomalies are correlated metric misbehaviours of implicated services.
+	MetricAnomalies []MetricAnomaly `json:"metric_anomalies,omitempty"`
+	// RecentChanges are deploys and flag flips to implicated services shortly before the trace.
+	RecentChanges []ChangeEvent `json:"recent_changes,omitempty"`
+	// SimilarIncidents are earlier analyses of traces that looked alike.
+	SimilarIncidents []SimilarIncident `json:"similar_incidents,omitempty"`
+	// Runbooks are the runbook sections matching the trace's services and facts.
+	Runbooks      []RunbookSection `json:"runbooks,omitempty"`
+	AIExplanation string           `json:"ai_explanation"` // For unary responses
+}
+
+// SimilarIncident is a past analysis retrieved by semantic similarity.
+type SimilarIncident struct {
+	TraceID     string    `json:"trace_id"`
+	Summary     string    `json:"summary"`
+	Explanation string    `json:"explanation"`
+	Score       float64   `json:"score"`
+	AnalyzedAt  time.Time `json:"analyzed_at"`
+}
+
+// RunbookSection is a section of a runbook, cited as File > Heading.
+type RunbookSection struct {
+	File    string  `json:"file"`
+	Heading string  `json:"heading"`
+	Content string  `json:"content"`
+	Score   float64 `json:"score"`
+}
+
+// PromptRef identifies the prompt template version that produced a result.
+type PromptRef struct {
+	Name    string `json:"name"`
+	Version int    `json:"version"`
 }
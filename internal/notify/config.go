@@ -0,0 +1,118 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Config is the JSON file format used to declare notification sinks.
+type Config struct {
+	DedupWindow string       `json:"dedup_window"`
+	Sinks       []SinkConfig `json:"sinks"`
+}
+
+type SinkConfig struct {
+	Type        string `json:"type"`
+	MinSeverity string `json:"min_severity"`
+	RateLimit   int    `json:"rate_limit"`
+	RatePeriod  string `json:"rate_period"`
+
+	// webhook, slack, pagerduty
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// pagerduty
+	RoutingKey string `json:"routing_key"`
+
+	// email
+	SMTPAddr string   `json:"smtp_addr"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+}
+
+// LoadConfig reads a notifier configuration file.
+func LoadConfig(path string) (*Notifier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notifier config: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse notifier config: %w", err)
+	}
+
+	return NewFromConfig(cfg)
+}
+
+func NewFromConfig(cfg Config) (*Notifier, error) {
+	dedupWindow := 10 * time.Minute
+	if cfg.DedupWindow != "" {
+		d, err := time.ParseDuration(cfg.DedupWindow)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dedup_window: %w", err)
+		}
+		dedupWindow = d
+	}
+
+	n := NewNotifier(dedupWindow)
+	for i, sc := range cfg.Sinks {
+		sink, err := newSink(sc)
+		if err != nil {
+			return nil, fmt.Errorf("sink %d: %w", i, err)
+		}
+
+		opts := SinkOptions{
+			MinSeverity: sc.MinSeverity,
+			RateLimit:   sc.RateLimit,
+		}
+		if sc.RatePeriod != "" {
+			d, err := time.ParseDuration(sc.RatePeriod)
+			if err != nil {
+				return nil, fmt.Errorf("sink %d: invalid rate_period: %w", i, err)
+			}
+			opts.RatePeriod = d
+		}
+
+		n.AddSink(sink, opts)
+	}
+
+	return n, nil
+}
+
+func newSink(sc SinkConfig) (Sink, error) {
+	switch sc.Type {
+	case "webhook":
+		if sc.URL == "" {
+			return nil, fmt.Errorf("webhook url is required")
+		}
+		return &WebhookSink{URL: sc.URL, Headers: sc.Headers}, nil
+	case "slack":
+		if sc.URL == "" {
+			return nil, fmt.Errorf("slack webhook url is required")
+		}
+		return &SlackSink{WebhookURL: sc.URL}, nil
+	case "pagerduty":
+		if sc.RoutingKey == "" {
+			return nil, fmt.Errorf("pagerduty routing_key is required")
+		}
+		return &PagerDutySink{RoutingKey: sc.RoutingKey, EventsURL: sc.URL}, nil
+	case "email":
+		if sc.SMTPAddr == "" || sc.From == "" {
+			return nil, fmt.Errorf("email smtp_addr and from are required")
+		}
+		return &EmailSink{
+			Addr:     sc.SMTPAddr,
+			From:     sc.From,
+			To:       sc.To,
+			Username: sc.Username,
+			Password: sc.Password,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported sink type: %s", sc.Type)
+	}
+}
@@ -0,0 +1,226 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+// Notification is the sink-agnostic alert produced by TraceMind.
+type Notification struct {
+	Title     string                 `json:"title"`
+	Message   string                 `json:"message"`
+	Severity  string                 `json:"severity"`
+	Source    string                 `json:"source"`
+	Service   string                 `json:"service,omitempty"`
+	DedupKey  string                 `json:"dedup_key"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// Sink delivers notifications to a single destination.
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, n Notification) error
+}
+
+// SinkOptions controls which notifications reach a sink and how often.
+type SinkOptions struct {
+	MinSeverity string
+	// RateLimit is the maximum number of notifications per RatePeriod; zero disables limiting.
+	RateLimit  int
+	RatePeriod time.Duration
+}
+
+type route struct {
+	sink    Sink
+	opts    SinkOptions
+	history []time.Time
+}
+
+// Notifier fans notifications out to its sinks, applying severity filters,
+// deduplication and per-sink rate limits.
+type Notifier struct {
+	mu          sync.Mutex
+	routes      []*route
+	dedupWindow time.Duration
+	delivered   map[string]time.Time
+}
+
+func NewNotifier(dedupWindow time.Duration) *Notifier {
+	return &Notifier{
+		dedupWindow: dedupWindow,
+		delivered:   make(map[string]time.Time),
+	}
+}
+
+func (n *Notifier) AddSink(sink Sink, opts SinkOptions) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if opts.RatePeriod == 0 {
+		opts.RatePeriod = time.Minute
+	}
+	n.routes = append(n.routes, &route{sink: sink, opts: opts})
+}
+
+// Notify delivers a notification to every eligible sink and returns the
+// combined delivery errors. Suppressed notifications are not errors.
+func (n *Notifier) Notify(ctx context.Context, notification Notification) error {
+	if notification.Timestamp.IsZero() {
+		notification.Timestamp = time.Now()
+	}
+
+	var errs []error
+	for _, d := range n.eligibleRoutes(notification) {
+		if err := d.route.sink.Send(ctx, notification); err != nil {
+			n.release(d)
+			errs = append(errs, fmt.Errorf("%s: %w", d.route.sink.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NotifyAsync delivers in the background and logs failures.
+func (n *Notifier) NotifyAsync(notification Notification) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := n.Notify(ctx, notification); err != nil {
+			log.Printf("Notification %q failed: %v", notification.DedupKey, err)
+		}
+	}()
+}
+
+// delivery is a route a notification is being sent to. Its rate limit slot
+// and dedup entry are reserved up front so concurrent notifications cannot
+// both pass the checks, and released again if the send fails.
+type delivery struct {
+	route *route
+	key   string
+	at    time.Time
+	// prev is the dedup entry the reservation replaced, if any.
+	prev    time.Time
+	hadPrev bool
+}
+
+func (n *Notifier) eligibleRoutes(notification Notification) []delivery {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := time.Now()
+	var deliveries []delivery
+	for _, r := range n.routes {
+		if severityRank(notification.Severity) < severityRank(r.opts.MinSeverity) {
+			continue
+		}
+
+		key := r.sink.Name() + "|" + notification.DedupKey
+		if notification.DedupKey != "" && n.dedupWindow > 0 {
+			if last, ok := n.delivered[key]; ok && now.Sub(last) < n.dedupWindow {
+				continue
+			}
+		}
+
+		if r.opts.RateLimit > 0 {
+			cutoff := now.Add(-r.opts.RatePeriod)
+			kept := r.history[:0]
+			for _, t := range r.history {
+				if t.After(cutoff) {
+					kept = append(kept, t)
+				}
+			}
+			r.history = kept
+			if len(r.history) >= r.opts.RateLimit {
+				log.Printf("Notification %q rate limited for sink %s", notification.DedupKey, r.sink.Name())
+				continue
+			}
+			r.history = append(r.history, now)
+		}
+
+		d := delivery{route: r, at: now}
+		if notification.DedupKey != "" {
+			d.key = key
+			d.prev, d.hadPrev = n.delivered[key]
+			n.delivered[key] = now
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries
+}
+
+// release gives back the rate limit slot and dedup entry of a failed
+// delivery, so that a retry is not suppressed.
+func (n *Notifier) release(d delivery) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	r := d.route
+	for i, t := range r.history {
+		if t.Equal(d.at) {
+			r.history = append(r.history[:i], r.history[i+1:]...)
+			break
+		}
+	}
+
+	if d.key == "" || !n.delivered[d.key].Equal(d.at) {
+		return
+	}
+	if d.hadPrev {
+		n.delivered[d.key] = d.prev
+	} else {
+		delete(n.delivered, d.key)
+	}
+}
+
+func severityRank(severity string) int {
+	switch severity {
+	case "critical":
+		return 3
+	case "error":
+		return 2
+	case "warning":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// FromIncident builds the notification sent when an incident opens.
+func FromIncident(inc models.Incident) Notification {
+	return Notification{
+		Title:    fmt.Sprintf("TraceMind incident: %s", inc.Trigger),
+		Message:  inc.Description,
+		Severity: inc.Severity,
+		Source:   "incident",
+		Service:  inc.Service,
+		DedupKey: fmt.Sprintf("incident/%s/%s", inc.Trigger, inc.Service),
+		Details: map[string]interface{}{
+			"incident_id":    inc.ID,
+			"observed_value": inc.ObservedValue,
+			"threshold":      inc.Threshold,
+			"trace_ids":      inc.TraceIDs,
+		},
+		Timestamp: inc.OpenedAt,
+	}
+}
+
+// FromFact builds the notification sent for a critical symbolic fact.
+func FromFact(traceID string, fact models.SymbolicFact) Notification {
+	return Notification{
+		Title:    fmt.Sprintf("TraceMind %s in %s", fact.Type, fact.Service),
+		Message:  fact.Description,
+		Severity: fact.Severity,
+		Source:   "symbolic_fact",
+		Service:  fact.Service,
+		DedupKey: fmt.Sprintf("fact/%s/%s", fact.Type, fact.Service),
+		Details: map[string]interface{}{
+			"trace_id": traceID,
+		},
+	}
+}
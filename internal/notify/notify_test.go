@@ -0,0 +1,288 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder is an HTTP stand-in that records the JSON bodies it receives and
+// answers with status.
+type recorder struct {
+	mu     sync.Mutex
+	bodies []map[string]interface{}
+	status int
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&body)
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.bodies = append(rec.bodies, body)
+	if rec.status != 0 {
+		w.WriteHeader(rec.status)
+	}
+}
+
+func (rec *recorder) count() int {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return len(rec.bodies)
+}
+
+func (rec *recorder) setStatus(status int) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.status = status
+}
+
+func newRecorder(t *testing.T) (*recorder, string) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	t.Cleanup(srv.Close)
+	return rec, srv.URL
+}
+
+func testNotification() Notification {
+	return Notification{
+		Title:     "TraceMind ERROR_ORIGIN in payment-service",
+		Message:   "payment-service returned 500",
+		Severity:  "critical",
+		Source:    "symbolic_fact",
+		Service:   "payment-service",
+		DedupKey:  "fact/ERROR_ORIGIN/payment-service",
+		Details:   map[string]interface{}{"trace_id": "abc"},
+		Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+func TestSinkPayloads(t *testing.T) {
+	rec, url := newRecorder(t)
+	ctx := context.Background()
+	n := testNotification()
+
+	if err := (&WebhookSink{URL: url}).Send(ctx, n); err != nil {
+		t.Fatalf("webhook: %v", err)
+	}
+	if err := (&SlackSink{WebhookURL: url}).Send(ctx, n); err != nil {
+		t.Fatalf("slack: %v", err)
+	}
+	if err := (&PagerDutySink{RoutingKey: "key", EventsURL: url}).Send(ctx, n); err != nil {
+		t.Fatalf("pagerduty: %v", err)
+	}
+
+	if got := rec.bodies[0]["dedup_key"]; got != n.DedupKey {
+		t.Errorf("webhook dedup_key = %v, want %q", got, n.DedupKey)
+	}
+	if got := rec.bodies[1]["text"]; got != "*"+n.Title+"*" {
+		t.Errorf("slack text = %v", got)
+	}
+	pd := rec.bodies[2]
+	if pd["routing_key"] != "key" || pd["event_action"] != "trigger" || pd["dedup_key"] != n.DedupKey {
+		t.Errorf("pagerduty event = %v", pd)
+	}
+	if payload, _ := pd["payload"].(map[string]interface{}); payload["severity"] != "critical" || payload["source"] != "payment-service" {
+		t.Errorf("pagerduty payload = %v", pd["payload"])
+	}
+}
+
+func TestSinkErrorStatus(t *testing.T) {
+	rec, url := newRecorder(t)
+	rec.setStatus(http.StatusBadGateway)
+
+	err := (&WebhookSink{URL: url}).Send(context.Background(), testNotification())
+	if err == nil || !strings.Contains(err.Error(), "502") {
+		t.Fatalf("err = %v, want unexpected status 502", err)
+	}
+}
+
+func TestNotifierFiltersAndDeduplicates(t *testing.T) {
+	rec, url := newRecorder(t)
+	n := NewNotifier(time.Minute)
+	n.AddSink(&WebhookSink{URL: url}, SinkOptions{MinSeverity: "error"})
+	ctx := context.Background()
+
+	warning := testNotification()
+	warning.Severity = "warning"
+	if err := n.Notify(ctx, warning); err != nil {
+		t.Fatal(err)
+	}
+	if rec.count() != 0 {
+		t.Fatalf("warning below min severity was delivered")
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := n.Notify(ctx, testNotification()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := rec.count(); got != 1 {
+		t.Fatalf("delivered %d duplicates, want 1", got)
+	}
+}
+
+func TestNotifierRateLimit(t *testing.T) {
+	rec, url := newRecorder(t)
+	n := NewNotifier(0)
+	n.AddSink(&WebhookSink{URL: url}, SinkOptions{RateLimit: 2, RatePeriod: time.Hour})
+
+	for i := 0; i < 5; i++ {
+		if err := n.Notify(context.Background(), testNotification()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := rec.count(); got != 2 {
+		t.Fatalf("delivered %d, want rate limit of 2", got)
+	}
+}
+
+func TestNotifierFailedSendIsNotRecorded(t *testing.T) {
+	rec, url := newRecorder(t)
+	rec.setStatus(http.StatusServiceUnavailable)
+	n := NewNotifier(time.Hour)
+	n.AddSink(&WebhookSink{URL: url}, SinkOptions{RateLimit: 1, RatePeriod: time.Hour})
+	ctx := context.Background()
+
+	if err := n.Notify(ctx, testNotification()); err == nil {
+		t.Fatal("expected the failed send to be reported")
+	}
+
+	// Neither the dedup entry nor the rate limit slot of the failed send
+	// may suppress the retry.
+	rec.setStatus(http.StatusOK)
+	if err := n.Notify(ctx, testNotification()); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if got := rec.count(); got != 2 {
+		t.Fatalf("sink received %d requests, want 2", got)
+	}
+
+	if err := n.Notify(ctx, testNotification()); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.count(); got != 2 {
+		t.Fatalf("successful send was not deduplicated")
+	}
+}
+
+// smtpServer is a minimal SMTP stand-in that accepts every message and
+// returns the raw DATA of each.
+func smtpServer(t *testing.T) (string, <-chan string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	messages := make(chan string, 1)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, messages)
+		}
+	}()
+	return ln.Addr().String(), messages
+}
+
+func serveSMTP(conn net.Conn, messages chan<- string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "DATA"):
+			reply("354 end with <CRLF>.<CRLF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			messages <- data.String()
+			reply("250 queued")
+		case strings.HasPrefix(cmd, "QUIT"):
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func TestEmailSink(t *testing.T) {
+	addr, messages := smtpServer(t)
+	sink := &EmailSink{Addr: addr, From: "tracemind@example.com", To: []string{"oncall@example.com"}}
+
+	n := testNotification()
+	n.Title = "TraceMind ERROR_ORIGIN in evil\r\nBcc: victim@example.com"
+	if err := sink.Send(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+
+	var msg string
+	select {
+	case msg = <-messages:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+	header, body, _ := strings.Cut(msg, "\r\n\r\n")
+	if !strings.Contains(header, "Subject: [CRITICAL] TraceMind ERROR_ORIGIN in evil Bcc: victim@example.com\r\n") {
+		t.Errorf("subject not flattened:\n%s", header)
+	}
+	for _, line := range strings.Split(header, "\r\n") {
+		if strings.HasPrefix(line, "Bcc:") {
+			t.Errorf("header injected: %q", line)
+		}
+	}
+	if !strings.Contains(body, "payment-service returned 500") || !strings.Contains(body, "trace_id: abc") {
+		t.Errorf("body missing message or details:\n%s", body)
+	}
+}
+
+func TestEmailSinkEncodesNonASCIISubject(t *testing.T) {
+	addr, messages := smtpServer(t)
+	sink := &EmailSink{Addr: addr, From: "tracemind@example.com", To: []string{"oncall@example.com"}}
+
+	n := testNotification()
+	n.Title = "Latenz in zahlungs-dienst überschritten"
+	if err := sink.Send(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+	msg := <-messages
+	if !strings.Contains(msg, "Subject: =?UTF-8?q?") {
+		t.Errorf("non-ASCII subject not RFC 2047 encoded:\n%s", msg)
+	}
+}
+
+func TestEmailSinkRequiresRecipients(t *testing.T) {
+	err := (&EmailSink{Addr: "127.0.0.1:1", From: "a@example.com"}).Send(context.Background(), testNotification())
+	if err == nil || !strings.Contains(err.Error(), "recipients") {
+		t.Fatalf("err = %v, want missing recipients", err)
+	}
+}
@@ -0,0 +1,221 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/smtp"
+	"sort"
+	"strings"
+)
+
+const DefaultPagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+
+// WebhookSink posts the notification as JSON to an arbitrary endpoint.
+type WebhookSink struct {
+	URL     string
+	Headers map[string]string
+	Client  *http.Client
+}
+
+func (s *WebhookSink) Name() string { return "webhook:" + s.URL }
+
+func (s *WebhookSink) Send(ctx context.Context, n Notification) error {
+	return postJSON(ctx, s.Client, s.URL, s.Headers, n)
+}
+
+// SlackSink posts to a Slack-compatible incoming webhook.
+type SlackSink struct {
+	WebhookURL string
+	Client     *http.Client
+}
+
+func (s *SlackSink) Name() string { return "slack:" + s.WebhookURL }
+
+func (s *SlackSink) Send(ctx context.Context, n Notification) error {
+	fields := []map[string]interface{}{
+		{"title": "Severity", "value": n.Severity, "short": true},
+		{"title": "Source", "value": n.Source, "short": true},
+	}
+	if n.Service != "" {
+		fields = append(fields, map[string]interface{}{"title": "Service", "value": n.Service, "short": true})
+	}
+
+	payload := map[string]interface{}{
+		"text": fmt.Sprintf("*%s*", n.Title),
+		"attachments": []map[string]interface{}{
+			{
+				"color":  slackColor(n.Severity),
+				"text":   n.Message,
+				"fields": fields,
+				"ts":     n.Timestamp.Unix(),
+			},
+		},
+	}
+	return postJSON(ctx, s.Client, s.WebhookURL, nil, payload)
+}
+
+func slackColor(severity string) string {
+	switch severity {
+	case "critical", "error":
+		return "danger"
+	case "warning":
+		return "warning"
+	default:
+		return "good"
+	}
+}
+
+// PagerDutySink sends Events API v2 trigger events.
+type PagerDutySink struct {
+	RoutingKey string
+	// EventsURL defaults to DefaultPagerDutyURL.
+	EventsURL string
+	Client    *http.Client
+}
+
+func (s *PagerDutySink) Name() string { return "pagerduty" }
+
+func (s *PagerDutySink) Send(ctx context.Context, n Notification) error {
+	url := s.EventsURL
+	if url == "" {
+		url = DefaultPagerDutyURL
+	}
+
+	source := n.Service
+	if source == "" {
+		source = "tracemind"
+	}
+
+	payload := map[string]interface{}{
+		"routing_key":  s.RoutingKey,
+		"event_action": "trigger",
+		"payload": map[string]interface{}{
+			"summary":        n.Title + ": " + n.Message,
+			"source":         source,
+			"severity":       pagerDutySeverity(n.Severity),
+			"timestamp":      n.Timestamp.Format("2006-01-02T15:04:05.000Z07:00"),
+			"component":      n.Service,
+			"class":          n.Source,
+			"custom_details": n.Details,
+		},
+	}
+	if n.DedupKey != "" {
+		payload["dedup_key"] = n.DedupKey
+	}
+	return postJSON(ctx, s.Client, url, nil, payload)
+}
+
+func pagerDutySeverity(severity string) string {
+	switch severity {
+	case "critical", "error", "warning":
+		return severity
+	default:
+		return "info"
+	}
+}
+
+// EmailSink delivers plain-text mail through an SMTP relay.
+type EmailSink struct {
+	Addr     string
+	From     string
+	To       []string
+	Username string
+	Password string
+}
+
+func (s *EmailSink) Name() string { return "email:" + s.Addr }
+
+func (s *EmailSink) Send(ctx context.Context, n Notification) error {
+	if len(s.To) == 0 {
+		return fmt.Errorf("no recipients configured")
+	}
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		host := s.Addr
+		if i := strings.LastIndex(host, ":"); i >= 0 {
+			host = host[:i]
+		}
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+
+	var body strings.Builder
+	subject := fmt.Sprintf("[%s] %s", strings.ToUpper(n.Severity), n.Title)
+	body.WriteString(fmt.Sprintf("From: %s\r\n", headerValue(s.From)))
+	body.WriteString(fmt.Sprintf("To: %s\r\n", headerValue(strings.Join(s.To, ", "))))
+	body.WriteString(fmt.Sprintf("Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", headerValue(subject))))
+	body.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	body.WriteString(n.Message + "\r\n\r\n")
+	if n.Service != "" {
+		body.WriteString(fmt.Sprintf("Service: %s\r\n", n.Service))
+	}
+	body.WriteString(fmt.Sprintf("Source: %s\r\n", n.Source))
+	body.WriteString(fmt.Sprintf("Time: %s\r\n", n.Timestamp.Format("2006-01-02 15:04:05 MST")))
+
+	keys := make([]string, 0, len(n.Details))
+	for k := range n.Details {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		body.WriteString(fmt.Sprintf("%s: %v\r\n", k, n.Details[k]))
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- smtp.SendMail(s.Addr, auth, s.From, s.To, []byte(body.String()))
+	}()
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			return fmt.Errorf("smtp send failed: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// headerValue flattens line breaks so that trace-derived text such as a
+// service name cannot end a mail header and inject new ones.
+func headerValue(v string) string {
+	return strings.Join(strings.FieldsFunc(v, func(r rune) bool { return r == '\r' || r == '\n' }), " ")
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, payload interface{}) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
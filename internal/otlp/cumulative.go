@@ -0,0 +1,84 @@
+package otlp
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+// cumulativeTTL is how long the previous point of a cumulative series is
+// remembered after it was last exported.
+const cumulativeTTL = time.Hour
+
+// Cumulative remembers the latest point of each cumulative series so that
+// the next one can be turned into the change since it. It is safe for
+// concurrent use.
+type Cumulative struct {
+	mu     sync.Mutex
+	last   map[string]cumulativePoint
+	pruned time.Time
+}
+
+type cumulativePoint struct {
+	start        int64Value
+	at           time.Time
+	value, count float64
+	seen         time.Time
+}
+
+func NewCumulative() *Cumulative {
+	return &Cumulative{last: make(map[string]cumulativePoint)}
+}
+
+// delta records a cumulative point of sample's series and returns how much
+// value and count grew since the previous one, and over how many seconds.
+// ok is false for the first point of a series, after the series was reset
+// and for points older than the previous one.
+func (c *Cumulative) delta(sample models.MetricSample, start int64Value, value, count float64) (dValue, dCount, seconds float64, ok bool) {
+	if c == nil {
+		return 0, 0, 0, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.pruned) > cumulativeTTL {
+		for key, p := range c.last {
+			if now.Sub(p.seen) > cumulativeTTL {
+				delete(c.last, key)
+			}
+		}
+		c.pruned = now
+	}
+
+	key := cumulativeKey(sample)
+	prev, found := c.last[key]
+	if found && !sample.Timestamp.After(prev.at) {
+		return 0, 0, 0, false
+	}
+	c.last[key] = cumulativePoint{start: start, at: sample.Timestamp, value: value, count: count, seen: now}
+
+	reset := (start != 0 && prev.start != 0 && start != prev.start) || value < prev.value || count < prev.count
+	if !found || reset {
+		return 0, 0, 0, false
+	}
+	return value - prev.value, count - prev.count, sample.Timestamp.Sub(prev.at).Seconds(), true
+}
+
+func cumulativeKey(sample models.MetricSample) string {
+	keys := make([]string, 0, len(sample.Labels))
+	for k := range sample.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString(sample.Service + "|" + sample.Name)
+	for _, k := range keys {
+		sb.WriteString("|" + k + "=" + sample.Labels[k])
+	}
+	return sb.String()
+}
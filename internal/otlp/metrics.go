@@ -0,0 +1,240 @@
+package otlp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+// The types below mirror the OTLP/HTTP JSON encoding of
+// ExportMetricsServiceRequest, limited to the fields TraceMind uses.
+
+type metricsRequest struct {
+	ResourceMetrics []resourceMetrics `json:"resourceMetrics"`
+}
+
+type resourceMetrics struct {
+	Resource     resource       `json:"resource"`
+	ScopeMetrics []scopeMetrics `json:"scopeMetrics"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeMetrics struct {
+	Metrics []metric `json:"metrics"`
+}
+
+type metric struct {
+	Name      string     `json:"name"`
+	Gauge     *numberSet `json:"gauge,omitempty"`
+	Sum       *numberSet `json:"sum,omitempty"`
+	Histogram *histogram `json:"histogram,omitempty"`
+}
+
+type numberSet struct {
+	DataPoints             []numberDataPoint `json:"dataPoints"`
+	AggregationTemporality temporality       `json:"aggregationTemporality"`
+	IsMonotonic            bool              `json:"isMonotonic"`
+}
+
+type numberDataPoint struct {
+	Attributes        []keyValue  `json:"attributes"`
+	StartTimeUnixNano int64Value  `json:"startTimeUnixNano"`
+	TimeUnixNano      int64Value  `json:"timeUnixNano"`
+	AsDouble          *float64    `json:"asDouble,omitempty"`
+	AsInt             *int64Value `json:"asInt,omitempty"`
+}
+
+type histogram struct {
+	DataPoints             []histogramDataPoint `json:"dataPoints"`
+	AggregationTemporality temporality          `json:"aggregationTemporality"`
+}
+
+type histogramDataPoint struct {
+	Attributes        []keyValue `json:"attributes"`
+	StartTimeUnixNano int64Value `json:"startTimeUnixNano"`
+	TimeUnixNano      int64Value `json:"timeUnixNano"`
+	Count             int64Value `json:"count"`
+	Sum               *float64   `json:"sum,omitempty"`
+}
+
+// temporality is an AggregationTemporality, which OTLP/JSON encodes as a
+// number or, from some exporters, as the enum name.
+type temporality int
+
+const temporalityCumulative temporality = 2
+
+func (t *temporality) UnmarshalJSON(data []byte) error {
+	switch s := strings.Trim(string(data), `"`); s {
+	case "", "null", "AGGREGATION_TEMPORALITY_UNSPECIFIED":
+		*t = 0
+	case "AGGREGATION_TEMPORALITY_DELTA":
+		*t = 1
+	case "AGGREGATION_TEMPORALITY_CUMULATIVE":
+		*t = temporalityCumulative
+	default:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("invalid aggregation temporality %s", data)
+		}
+		*t = temporality(n)
+	}
+	return nil
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string     `json:"stringValue,omitempty"`
+	BoolValue   *bool       `json:"boolValue,omitempty"`
+	IntValue    *int64Value `json:"intValue,omitempty"`
+	DoubleValue *float64    `json:"doubleValue,omitempty"`
+}
+
+func (v anyValue) String() string {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return strconv.FormatBool(*v.BoolValue)
+	case v.IntValue != nil:
+		return strconv.FormatInt(int64(*v.IntValue), 10)
+	case v.DoubleValue != nil:
+		return strconv.FormatFloat(*v.DoubleValue, 'g', -1, 64)
+	}
+	return ""
+}
+
+// int64Value accepts both the string form mandated by OTLP/JSON and plain numbers.
+type int64Value int64
+
+func (v *int64Value) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*v = 0
+		return nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid int64 %s: %w", data, err)
+	}
+	*v = int64Value(n)
+	return nil
+}
+
+// ParseMetricsJSON converts an OTLP/HTTP JSON metrics export into samples.
+// Gauges, delta sums and non-monotonic sums are taken as they are.
+// Histograms are reduced to their mean as "<name>_avg". Cumulative points
+// only grow, so monotonic cumulative sums become the per-second rate since
+// the series' previous point as "<name>_rate", and cumulative histograms
+// the mean of the observations since then. That needs the previous point,
+// which cumulative remembers across exports; without it those points are
+// skipped.
+func ParseMetricsJSON(data []byte, cumulative *Cumulative) ([]models.MetricSample, error) {
+	var req metricsRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("invalid OTLP metrics payload: %w", err)
+	}
+
+	var samples []models.MetricSample
+	for _, rm := range req.ResourceMetrics {
+		service := "unknown"
+		for _, kv := range rm.Resource.Attributes {
+			if kv.Key == "service.name" {
+				service = kv.Value.String()
+			}
+		}
+
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				var points []numberDataPoint
+				counter := false
+				if m.Gauge != nil {
+					points = append(points, m.Gauge.DataPoints...)
+				}
+				if m.Sum != nil {
+					points = append(points, m.Sum.DataPoints...)
+					counter = m.Sum.IsMonotonic && m.Sum.AggregationTemporality == temporalityCumulative
+				}
+
+				for _, dp := range points {
+					var value float64
+					switch {
+					case dp.AsDouble != nil:
+						value = *dp.AsDouble
+					case dp.AsInt != nil:
+						value = float64(*dp.AsInt)
+					default:
+						continue
+					}
+					sample := newSample(service, m.Name, dp.Attributes, dp.TimeUnixNano, value)
+					if counter {
+						sample.Name += "_rate"
+						change, _, seconds, ok := cumulative.delta(sample, dp.StartTimeUnixNano, value, 0)
+						if !ok || seconds <= 0 {
+							continue
+						}
+						sample.Value = change / seconds
+					}
+					samples = append(samples, sample)
+				}
+
+				if m.Histogram != nil {
+					for _, dp := range m.Histogram.DataPoints {
+						if dp.Sum == nil {
+							continue
+						}
+						sum, count := *dp.Sum, float64(dp.Count)
+						sample := newSample(service, m.Name+"_avg", dp.Attributes, dp.TimeUnixNano, 0)
+						if m.Histogram.AggregationTemporality == temporalityCumulative {
+							var ok bool
+							if sum, count, _, ok = cumulative.delta(sample, dp.StartTimeUnixNano, sum, count); !ok {
+								continue
+							}
+						}
+						if count <= 0 {
+							continue
+						}
+						sample.Value = sum / count
+						samples = append(samples, sample)
+					}
+				}
+			}
+		}
+	}
+
+	return samples, nil
+}
+
+func newSample(service, name string, attrs []keyValue, ts int64Value, value float64) models.MetricSample {
+	sample := models.MetricSample{
+		Service:   service,
+		Name:      name,
+		Timestamp: time.Unix(0, int64(ts)),
+		Value:     value,
+	}
+	if ts == 0 {
+		sample.Timestamp = time.Now()
+	}
+
+	for _, kv := range attrs {
+		if kv.Key == "service.name" {
+			sample.Service = kv.Value.String()
+			continue
+		}
+		if sample.Labels == nil {
+			sample.Labels = make(map[string]string)
+		}
+		sample.Labels[kv.Key] = kv.Value.String()
+	}
+	return sample
+}
@@ -0,0 +1,368 @@
+// Package prompt holds the named, versioned text/template prompts used for
+// LLM calls, so prompts can be iterated without redeploying and every
+// result can be traced to the prompt that produced it.
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	SourceBuiltin = "builtin"
+	SourceFile    = "file"
+	SourceAPI     = "api"
+)
+
+// Template is one version of a named prompt. System and User are Go
+// text/template sources rendered with call-specific data.
+type Template struct {
+	Name      string    `json:"name" yaml:"name"`
+	Version   int       `json:"version" yaml:"version"`
+	System    string    `json:"system" yaml:"system"`
+	User      string    `json:"user" yaml:"user"`
+	Source    string    `json:"source" yaml:"-"`
+	CreatedAt time.Time `json:"created_at" yaml:"-"`
+
+	system, user *template.Template
+}
+
+// Summary describes a named prompt and its versions.
+type Summary struct {
+	Name          string `json:"name"`
+	ActiveVersion int    `json:"active_version"`
+	Versions      []int  `json:"versions"`
+}
+
+var (
+	mu sync.RWMutex
+	// templates holds every version of each prompt, oldest first.
+	templates = make(map[string][]*Template)
+	active    = make(map[string]int)
+	// revision changes whenever any active prompt does.
+	revision int
+	// dir is where versions created through the API are saved.
+	dir string
+	// samples are the zero values of the data each prompt is rendered
+	// with, which new versions must render against before they are used.
+	samples = make(map[string]any)
+)
+
+// Register adds a built-in prompt as version 1. sample is a value of the
+// type the prompt is rendered with; later versions must render with it.
+// Register panics on an invalid template, since built-ins are registered at
+// init.
+func Register(name, system, user string, sample any) {
+	t := &Template{Name: name, Version: 1, System: system, User: user, Source: SourceBuiltin}
+	if err := t.compile(); err != nil {
+		panic(err)
+	}
+	if err := t.dryRun(sample); err != nil {
+		panic(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	templates[name] = []*Template{t}
+	active[name] = 1
+	samples[name] = sample
+	revision++
+}
+
+// LoadDir loads every .yaml file in path as a new version of the prompt it
+// names, and saves later API-created versions there. Files with a version,
+// such as those saved by Create, keep it. Files without one take, in file
+// name order, the lowest versions no other file or built-in has, so neither
+// kind is renumbered across restarts and versions saved through the API
+// stay newer than the files they were created after. The newest version
+// of each prompt is active.
+func LoadDir(path string) error {
+	mu.Lock()
+	dir = path
+	mu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(path, "*.yaml"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	type loadedFile struct {
+		file string
+		t    Template
+	}
+	loaded := make([]loadedFile, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt %s: %w", file, err)
+		}
+		var t Template
+		if err := yaml.Unmarshal(data, &t); err != nil {
+			return fmt.Errorf("failed to parse prompt %s: %w", file, err)
+		}
+		if t.Name == "" {
+			t.Name = strings.TrimSuffix(filepath.Base(file), ".yaml")
+		}
+		t.Source = SourceFile
+		if info, err := os.Stat(file); err == nil {
+			t.CreatedAt = info.ModTime()
+		}
+		loaded = append(loaded, loadedFile{file: file, t: t})
+	}
+
+	used := make(map[string]map[int]bool)
+	claim := func(name string, version int) {
+		if used[name] == nil {
+			used[name] = make(map[int]bool)
+		}
+		used[name][version] = true
+	}
+	mu.RLock()
+	for name, versions := range templates {
+		for _, t := range versions {
+			claim(name, t.Version)
+		}
+	}
+	mu.RUnlock()
+	for _, l := range loaded {
+		if l.t.Version != 0 {
+			claim(l.t.Name, l.t.Version)
+		}
+	}
+	for i := range loaded {
+		t := &loaded[i].t
+		if t.Version != 0 {
+			continue
+		}
+		t.Version = 1
+		for used[t.Name][t.Version] {
+			t.Version++
+		}
+		claim(t.Name, t.Version)
+	}
+	sort.SliceStable(loaded, func(i, j int) bool { return loaded[i].t.Version < loaded[j].t.Version })
+
+	for _, l := range loaded {
+		if _, err := add(l.t); err != nil {
+			return fmt.Errorf("prompt %s: %w", l.file, err)
+		}
+	}
+	return nil
+}
+
+// Create adds a new version of a prompt and makes it active. The version is
+// saved to the prompt directory when one was loaded.
+func Create(t Template) (Template, error) {
+	if t.Name == "" || strings.ContainsAny(t.Name, `/\.`) {
+		return Template{}, errors.New("prompt name is required and must not contain '/', '\\' or '.'")
+	}
+	t.Version = 0
+	t.Source = SourceAPI
+	t.CreatedAt = time.Now()
+
+	created, err := add(t)
+	if err != nil {
+		return Template{}, err
+	}
+
+	mu.RLock()
+	saveDir := dir
+	mu.RUnlock()
+	if saveDir != "" {
+		if err := os.MkdirAll(saveDir, 0o755); err != nil {
+			return created, fmt.Errorf("failed to save prompt: %w", err)
+		}
+		data, err := yaml.Marshal(created)
+		if err != nil {
+			return created, err
+		}
+		path := filepath.Join(saveDir, fmt.Sprintf("%s.v%03d.yaml", created.Name, created.Version))
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return created, fmt.Errorf("failed to save prompt: %w", err)
+		}
+	}
+	return created, nil
+}
+
+func add(t Template) (Template, error) {
+	if err := t.compile(); err != nil {
+		return Template{}, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if sample, ok := samples[t.Name]; ok {
+		if err := t.dryRun(sample); err != nil {
+			return Template{}, err
+		}
+	}
+
+	versions := templates[t.Name]
+	latest := 0
+	if len(versions) > 0 {
+		latest = versions[len(versions)-1].Version
+	}
+	if t.Version == 0 {
+		t.Version = latest + 1
+	} else if t.Version <= latest {
+		return Template{}, fmt.Errorf("version %d of %q is not newer than %d", t.Version, t.Name, latest)
+	}
+
+	templates[t.Name] = append(versions, &t)
+	active[t.Name] = t.Version
+	revision++
+	return t, nil
+}
+
+// Activate makes an existing version the one used, e.g. to roll back.
+// Activation is not persisted: on restart the newest version is active.
+func Activate(name string, version int) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if find(name, version) == nil {
+		return fmt.Errorf("prompt %q has no version %d", name, version)
+	}
+	active[name] = version
+	revision++
+	return nil
+}
+
+// Get returns the active version of a prompt.
+func Get(name string) (Template, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	t := find(name, active[name])
+	if t == nil {
+		return Template{}, false
+	}
+	return *t, true
+}
+
+// Versions returns every version of a prompt, oldest first.
+func Versions(name string) []Template {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	out := make([]Template, 0, len(templates[name]))
+	for _, t := range templates[name] {
+		out = append(out, *t)
+	}
+	return out
+}
+
+// List summarizes every prompt by name.
+func List() []Summary {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	summaries := make([]Summary, 0, len(templates))
+	for name, versions := range templates {
+		s := Summary{Name: name, ActiveVersion: active[name]}
+		for _, t := range versions {
+			s.Versions = append(s.Versions, t.Version)
+		}
+		summaries = append(summaries, s)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Name < summaries[j].Name })
+	return summaries
+}
+
+// Revision changes whenever a prompt is added or activated, for caches of
+// prompt output.
+func Revision() int {
+	mu.RLock()
+	defer mu.RUnlock()
+	return revision
+}
+
+// Render executes the active version of a prompt with data.
+func Render(name string, data any) (system, user string, ref models.PromptRef, err error) {
+	t, ok := Get(name)
+	if !ok {
+		return "", "", models.PromptRef{}, fmt.Errorf("unknown prompt %q", name)
+	}
+	ref = models.PromptRef{Name: t.Name, Version: t.Version}
+
+	var sb strings.Builder
+	if err := t.system.Execute(&sb, data); err != nil {
+		return "", "", ref, fmt.Errorf("prompt %s v%d system: %w", t.Name, t.Version, err)
+	}
+	system = sb.String()
+
+	sb.Reset()
+	if err := t.user.Execute(&sb, data); err != nil {
+		return "", "", ref, fmt.Errorf("prompt %s v%d user: %w", t.Name, t.Version, err)
+	}
+	return system, sb.String(), ref, nil
+}
+
+func find(name string, version int) *Template {
+	for _, t := range templates[name] {
+		if t.Version == version {
+			return t
+		}
+	}
+	return nil
+}
+
+func (t *Template) compile() error {
+	if strings.TrimSpace(t.User) == "" {
+		return errors.New("user template is required")
+	}
+	var err error
+	if t.system, err = Parse(t.Name+".system", t.System); err != nil {
+		return fmt.Errorf("invalid system template: %w", err)
+	}
+	if t.user, err = Parse(t.Name+".user", t.User); err != nil {
+		return fmt.Errorf("invalid user template: %w", err)
+	}
+	return nil
+}
+
+// funcs are what templates can call besides text/template's built-ins.
+var funcs = template.FuncMap{
+	"join": strings.Join,
+	// percent turns a ratio into a percentage.
+	"percent": func(ratio float64) float64 { return ratio * 100 },
+	// excerpt collapses whitespace and cuts text to at most n runes.
+	"excerpt": func(n int, text string) string {
+		runes := []rune(strings.Join(strings.Fields(text), " "))
+		if len(runes) <= n {
+			return string(runes)
+		}
+		return string(runes[:n]) + "…"
+	},
+}
+
+// Parse compiles template text the way prompt versions are compiled, with
+// the same functions available.
+func Parse(name, text string) (*template.Template, error) {
+	return template.New(name).Option("missingkey=error").Funcs(funcs).Parse(text)
+}
+
+// dryRun renders the template with sample data, catching references to
+// fields the prompt's data does not have before the version is used.
+func (t *Template) dryRun(sample any) error {
+	if err := t.system.Execute(io.Discard, sample); err != nil {
+		return fmt.Errorf("system template does not render: %w", err)
+	}
+	if err := t.user.Execute(io.Discard, sample); err != nil {
+		return fmt.Errorf("user template does not render: %w", err)
+	}
+	return nil
+}
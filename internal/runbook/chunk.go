@@ -0,0 +1,134 @@
+package runbook
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maxChunkChars bounds a section quoted in a prompt; longer sections are
+// split at paragraph breaks.
+const maxChunkChars = 1500
+
+// Split breaks a markdown runbook into one chunk per section, each under
+// the path of headings above it. Headings inside code fences are ignored.
+func Split(file, content string) []Chunk {
+	var chunks []Chunk
+	var headings []string
+	var body []string
+	inFence := false
+
+	flush := func() {
+		text := strings.TrimSpace(strings.Join(body, "\n"))
+		body = body[:0]
+		if text == "" {
+			return
+		}
+		var path []string
+		for _, h := range headings {
+			// Skipped heading levels leave empty entries.
+			if h != "" {
+				path = append(path, h)
+			}
+		}
+		heading := strings.Join(path, " > ")
+		for _, part := range splitParagraphs(text) {
+			chunks = append(chunks, newChunk(file, heading, part))
+		}
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+		level := headingLevel(trimmed)
+		if inFence || level == 0 {
+			body = append(body, line)
+			continue
+		}
+
+		flush()
+		for len(headings) >= level {
+			headings = headings[:len(headings)-1]
+		}
+		for len(headings) < level-1 {
+			headings = append(headings, "")
+		}
+		headings = append(headings, strings.TrimSpace(strings.TrimLeft(trimmed, "#")))
+	}
+	flush()
+	return chunks
+}
+
+func headingLevel(line string) int {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || level == len(line) || line[level] != ' ' {
+		return 0
+	}
+	return level
+}
+
+func splitParagraphs(text string) []string {
+	if len(text) <= maxChunkChars {
+		return []string{text}
+	}
+
+	var parts []string
+	var current strings.Builder
+	for _, para := range strings.Split(text, "\n\n") {
+		if current.Len() > 0 && current.Len()+len(para)+2 > maxChunkChars {
+			parts = append(parts, current.String())
+			current.Reset()
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n\n")
+		}
+		current.WriteString(para)
+	}
+	if current.Len() > 0 {
+		parts = append(parts, current.String())
+	}
+	return parts
+}
+
+func newChunk(file, heading, content string) Chunk {
+	c := Chunk{
+		File:         file,
+		Heading:      heading,
+		Content:      content,
+		terms:        make(map[string]int),
+		headingTerms: make(map[string]bool),
+	}
+	for _, t := range tokenize(content) {
+		c.terms[t]++
+	}
+	for _, t := range tokenize(strings.TrimSuffix(file, ".md") + " " + heading) {
+		c.terms[t]++
+		c.headingTerms[t] = true
+	}
+	return c
+}
+
+var stopwords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "to": true, "of": true,
+	"in": true, "on": true, "is": true, "if": true, "it": true, "be": true,
+	"or": true, "an": true, "as": true, "at": true, "by": true, "this": true,
+}
+
+// tokenize lowercases text and splits it into words, so "payment-service"
+// and "HIGH_LATENCY" yield their parts.
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := words[:0]
+	for _, w := range words {
+		if len(w) > 1 && !stopwords[w] {
+			terms = append(terms, w)
+		}
+	}
+	return terms
+}
@@ -0,0 +1,112 @@
+package runbook
+
+import (
+	"math"
+	"sort"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+// Query term weights: services say where to look, fact types say what
+// went wrong, error messages add detail.
+const (
+	serviceWeight  = 2.0
+	factWeight     = 1.0
+	messageWeight  = 0.5
+	headingBoost   = 1.0
+	termSaturation = 1.2
+)
+
+// Retrieve returns the k runbook sections that best match the services,
+// fact types and error messages of an analysis, best first. A section must
+// match at least one service or fact type term.
+func (s *Store) Retrieve(analysis models.TraceAnalysis, k int) []models.RunbookSection {
+	query := queryTerms(analysis)
+	if len(query) == 0 || k <= 0 {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type scored struct {
+		chunk *Chunk
+		score float64
+	}
+	var results []scored
+	for file := range s.chunks {
+		for i := range s.chunks[file] {
+			c := &s.chunks[file][i]
+			score, anchored := 0.0, false
+			for term, weight := range query {
+				tf := c.terms[term]
+				if tf == 0 {
+					continue
+				}
+				if weight >= factWeight {
+					anchored = true
+				}
+				df := float64(s.df[term])
+				idf := math.Log(1 + (float64(s.total)-df+0.5)/(df+0.5))
+				match := float64(tf) / (float64(tf) + termSaturation)
+				if c.headingTerms[term] {
+					match += headingBoost
+				}
+				score += weight * idf * match
+			}
+			if anchored && score > 0 {
+				results = append(results, scored{chunk: c, score: score})
+			}
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		if results[i].chunk.File != results[j].chunk.File {
+			return results[i].chunk.File < results[j].chunk.File
+		}
+		return results[i].chunk.Heading < results[j].chunk.Heading
+	})
+	if len(results) > k {
+		results = results[:k]
+	}
+
+	sections := make([]models.RunbookSection, len(results))
+	for i, r := range results {
+		sections[i] = models.RunbookSection{
+			File:    r.chunk.File,
+			Heading: r.chunk.Heading,
+			Content: r.chunk.Content,
+			Score:   r.score,
+		}
+	}
+	return sections
+}
+
+// queryTerms weighs the terms of an analysis, keeping the highest weight
+// of a term that appears in several roles.
+func queryTerms(analysis models.TraceAnalysis) map[string]float64 {
+	terms := make(map[string]float64)
+	add := func(text string, weight float64) {
+		for _, t := range tokenize(text) {
+			if weight > terms[t] {
+				terms[t] = weight
+			}
+		}
+	}
+
+	for _, f := range analysis.SymbolicFacts {
+		add(f.Service, serviceWeight)
+		add(f.Type, factWeight)
+	}
+	for _, span := range analysis.Trace.Spans {
+		if span.Status.Code != "ERROR" {
+			continue
+		}
+		add(span.Name, serviceWeight)
+		add(span.Status.Message, messageWeight)
+	}
+	return terms
+}
@@ -0,0 +1,177 @@
+// Package runbook keeps a searchable knowledge base of markdown runbooks so
+// remediation advice can be grounded in, and cite, real procedures.
+package runbook
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Chunk is one section of a runbook under its heading path.
+type Chunk struct {
+	File    string `json:"file"`
+	Heading string `json:"heading"`
+	Content string `json:"content"`
+
+	terms map[string]int
+	// headingTerms holds the terms of the file name and heading path,
+	// which weigh more than body text.
+	headingTerms map[string]bool
+}
+
+// FileInfo describes an indexed runbook file.
+type FileInfo struct {
+	File     string `json:"file"`
+	Sections int    `json:"sections"`
+}
+
+// Store is an in-memory index of runbook sections. When dir is set,
+// uploaded runbooks are also written there so they survive restarts.
+type Store struct {
+	dir string
+
+	mu     sync.RWMutex
+	chunks map[string][]Chunk // by file
+	df     map[string]int     // number of chunks containing each term
+	total  int
+}
+
+// NewStore imports every markdown file under dir. An empty dir starts an
+// empty, memory-only store.
+func NewStore(dir string) (*Store, error) {
+	s := &Store{
+		dir:    dir,
+		chunks: make(map[string][]Chunk),
+		df:     make(map[string]int),
+	}
+	if dir == "" {
+		return s, nil
+	}
+	if err := s.Import(dir); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Import indexes every .md file under dir, named by their path relative to it.
+func (s *Store) Import(dir string) error {
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !isMarkdown(path) {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		s.set(filepath.ToSlash(rel), string(content))
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to import runbooks: %w", err)
+	}
+	return nil
+}
+
+// Add indexes an uploaded runbook, replacing any runbook of the same name.
+func (s *Store) Add(name, content string) (FileInfo, error) {
+	name = filepath.Base(filepath.Clean(name))
+	if !isMarkdown(name) || name == "." {
+		return FileInfo{}, fmt.Errorf("runbook name must be a .md file name")
+	}
+	if s.dir != "" {
+		if err := os.MkdirAll(s.dir, 0o755); err != nil {
+			return FileInfo{}, err
+		}
+		if err := os.WriteFile(filepath.Join(s.dir, name), []byte(content), 0o644); err != nil {
+			return FileInfo{}, fmt.Errorf("failed to save runbook: %w", err)
+		}
+	}
+	return FileInfo{File: name, Sections: s.set(name, content)}, nil
+}
+
+// Delete removes a runbook, reporting whether it existed.
+func (s *Store) Delete(name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.chunks[name]; !ok {
+		return false, nil
+	}
+	s.reindex(name, nil)
+	if s.dir != "" {
+		err := os.Remove(filepath.Join(s.dir, filepath.FromSlash(name)))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// List returns the indexed runbooks by name.
+func (s *Store) List() []FileInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	files := make([]FileInfo, 0, len(s.chunks))
+	for name, chunks := range s.chunks {
+		files = append(files, FileInfo{File: name, Sections: len(chunks)})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].File < files[j].File })
+	return files
+}
+
+func (s *Store) set(name, content string) int {
+	chunks := Split(name, content)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reindex(name, chunks)
+	return len(chunks)
+}
+
+// reindex replaces the chunks of a file and updates document frequencies.
+// The caller holds s.mu.
+func (s *Store) reindex(name string, chunks []Chunk) {
+	for _, c := range s.chunks[name] {
+		for term := range c.terms {
+			s.df[term]--
+			if s.df[term] == 0 {
+				delete(s.df, term)
+			}
+		}
+		s.total--
+	}
+	delete(s.chunks, name)
+
+	if len(chunks) == 0 {
+		return
+	}
+	for _, c := range chunks {
+		for term := range c.terms {
+			s.df[term]++
+		}
+		s.total++
+	}
+	s.chunks[name] = chunks
+}
+
+func isMarkdown(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".md" || ext == ".markdown"
+}
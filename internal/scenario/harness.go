@@ -0,0 +1,104 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gigikoneti/tracemind/internal/analyzer"
+	"github.com/gigikoneti/tracemind/internal/memory"
+	"github.com/gigikoneti/tracemind/internal/tracegen"
+)
+
+// Result reports whether a scenario was detected: every trace produced
+// the expected fact types, none of the absent ones, and blamed the
+// injected root cause.
+type Result struct {
+	Scenario string `json:"scenario"`
+	Detected bool   `json:"detected"`
+	Traces   int    `json:"traces"`
+	// Facts are the fact types produced for any trace.
+	Facts []string `json:"facts"`
+	// Missing are expected types some trace lacked; Unexpected are absent
+	// types some trace produced.
+	Missing    []string `json:"missing,omitempty"`
+	Unexpected []string `json:"unexpected,omitempty"`
+	// Misattributed are blamed types some trace produced only for other
+	// services than its root cause.
+	Misattributed []string `json:"misattributed,omitempty"`
+	// KnownGaps are the scenario's gap types some trace produced only for
+	// other services than its root cause; they do not fail the trace.
+	KnownGaps []string `json:"known_gaps,omitempty"`
+	// FailedCases names the traces that missed.
+	FailedCases []string `json:"failed_cases,omitempty"`
+}
+
+// Run generates traces traces of each scenario with seed and analyzes each
+// through a fresh analyzer.Pipeline, which applies analyzer.AnalyzeTrace
+// and the correlation rules on top of it.
+func Run(ctx context.Context, scenarios []Scenario, traces int, seed int64) ([]Result, error) {
+	results := make([]Result, 0, len(scenarios))
+	for _, s := range scenarios {
+		topology := s.Topology
+		topology.Name = s.Name
+		gen, err := tracegen.NewGenerator(topology, seed)
+		if err != nil {
+			return nil, fmt.Errorf("scenario %s: %w", s.Name, err)
+		}
+
+		r := Result{Scenario: s.Name, Traces: traces}
+		seen, missing, unexpected, misattributed, gaps := make(map[string]bool), make(map[string]bool), make(map[string]bool), make(map[string]bool), make(map[string]bool)
+		for _, c := range gen.Generate(traces, time.Now(), time.Second).Cases {
+			pipeline := &analyzer.Pipeline{Memory: memory.NewStore(traces), ChangeWindow: 30 * time.Minute}
+			got, blamed := make(map[string]bool), make(map[string]bool)
+			for _, f := range pipeline.Analyze(ctx, c.Trace).SymbolicFacts {
+				got[f.Type] = true
+				seen[f.Type] = true
+				if c.Labels != nil && f.Service == c.Labels.RootCauseService {
+					blamed[f.Type] = true
+				}
+			}
+
+			failed := false
+			for _, t := range s.Expect {
+				if !got[t] {
+					missing[t], failed = true, true
+				}
+			}
+			for _, t := range s.Absent {
+				if got[t] {
+					unexpected[t], failed = true, true
+				}
+			}
+			for _, t := range s.Blame {
+				if got[t] && !blamed[t] {
+					misattributed[t], failed = true, true
+				}
+			}
+			for _, t := range s.Gaps {
+				if got[t] && !blamed[t] {
+					gaps[t] = true
+				}
+			}
+			if failed {
+				r.FailedCases = append(r.FailedCases, c.ID)
+			}
+		}
+
+		r.Facts, r.Missing, r.Unexpected, r.Misattributed = sortedKeys(seen), sortedKeys(missing), sortedKeys(unexpected), sortedKeys(misattributed)
+		r.KnownGaps = sortedKeys(gaps)
+		r.Detected = len(r.FailedCases) == 0
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
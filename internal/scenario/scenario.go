@@ -0,0 +1,119 @@
+// Package scenario is a catalog of named failure scenarios, each a trace
+// template and the facts the analyzer must report for it, run as a
+// regression harness over the analyzer.
+package scenario
+
+import "github.com/gigikoneti/tracemind/internal/tracegen"
+
+// Scenario is a failure to reproduce: a topology whose faults hit every
+// trace, and the fact types analyzing such a trace must and must not give
+// and which of them must blame the injected root cause.
+//
+// Gaps lists expected fact types the analyzer is known to attribute to
+// another service than the root cause. They are reported rather than
+// failed, and move to Blame once the analyzer attributes them correctly.
+type Scenario struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Topology    tracegen.Topology `json:"topology"`
+	// Expect are the fact types every trace must produce.
+	Expect []string `json:"expect"`
+	// Absent are the fact types no trace may produce.
+	Absent []string `json:"absent,omitempty"`
+	// Blame are the expected fact types that must name the service the
+	// generator labeled as the root cause.
+	Blame []string `json:"blame,omitempty"`
+	// Gaps are the expected fact types known to name another service.
+	Gaps []string `json:"gaps,omitempty"`
+}
+
+// Catalog returns the built-in scenarios.
+func Catalog() []Scenario {
+	return []Scenario{
+		{
+			Name:        "cascading_timeout",
+			Description: "payment-service hangs; checkout-service times out after 1.5s and the failure propagates to the edge.",
+			Topology: tracegen.Topology{
+				Root: "frontend",
+				Services: []tracegen.Service{
+					{Name: "frontend", Latency: tracegen.Latency{Distribution: "normal", MeanMs: 15, StdDevMs: 3, MinMs: 5}, Calls: []tracegen.Call{{Service: "api-gateway", TimeoutMs: 3000}}},
+					{Name: "api-gateway", Latency: tracegen.Latency{MeanMs: 5}, Calls: []tracegen.Call{{Service: "checkout-service", TimeoutMs: 2500}}},
+					{Name: "checkout-service", Latency: tracegen.Latency{Distribution: "lognormal", MeanMs: 30, StdDevMs: 10}, Calls: []tracegen.Call{{Service: "payment-service", TimeoutMs: 1500}}},
+					{Name: "payment-service", Latency: tracegen.Latency{Distribution: "uniform", MinMs: 40, MaxMs: 90}, Calls: []tracegen.Call{{Service: "fraud-service"}}},
+					{Name: "fraud-service", Latency: tracegen.Latency{MeanMs: 20}},
+				},
+				Faults: []tracegen.Fault{{Kind: tracegen.FaultTimeout, Service: "payment-service"}},
+			},
+			Expect: []string{"ERROR_ORIGIN", "LATENCY_BOTTLENECK"},
+			Gaps:   []string{"ERROR_ORIGIN", "LATENCY_BOTTLENECK"},
+		},
+		{
+			Name:        "db_pool_exhaustion",
+			Description: "orders-service fans out 20 queries against a pool of 10 connections; queries queue and then fail to get a connection.",
+			Topology: tracegen.Topology{
+				Root: "checkout-service",
+				Services: []tracegen.Service{
+					{Name: "checkout-service", Latency: tracegen.Latency{MeanMs: 20}, Calls: []tracegen.Call{{Service: "orders-service", TimeoutMs: 5000}}},
+					{Name: "orders-service", Latency: tracegen.Latency{Distribution: "normal", MeanMs: 25, StdDevMs: 5, MinMs: 10}, Calls: []tracegen.Call{{Service: "orders-db", Count: 20, Parallel: true}}},
+					{Name: "orders-db", Latency: tracegen.Latency{Distribution: "lognormal", MeanMs: 6, StdDevMs: 3}, Attributes: map[string]any{"db.system": "postgresql", "db.pool.max_connections": 10}},
+				},
+				Faults: []tracegen.Fault{
+					{Kind: tracegen.FaultSlow, Service: "orders-db", DelayMs: 1200},
+					{Kind: tracegen.FaultErrorBurst, Service: "orders-db", Message: "timeout acquiring connection from pool (10/10 in use)"},
+				},
+			},
+			Expect: []string{"ERROR_ORIGIN", "LATENCY_BOTTLENECK"},
+			Gaps:   []string{"ERROR_ORIGIN", "LATENCY_BOTTLENECK"},
+		},
+		{
+			Name:        "retry_storm",
+			Description: "payment-service sheds load with 503s; checkout-service retries each of its three parallel calls up to five times with backoff.",
+			Topology: tracegen.Topology{
+				Root: "checkout-service",
+				Services: []tracegen.Service{
+					{Name: "checkout-service", Latency: tracegen.Latency{MeanMs: 20}, Calls: []tracegen.Call{{Service: "payment-service", Count: 3, Parallel: true}}},
+					{Name: "payment-service", Latency: tracegen.Latency{Distribution: "uniform", MinMs: 60, MaxMs: 100}},
+				},
+				Faults: []tracegen.Fault{{Kind: tracegen.FaultRetry, Service: "payment-service", Attempts: 5, Message: "503 Service Unavailable: load shedding"}},
+			},
+			Expect: []string{"ERROR_ORIGIN", "LATENCY_BOTTLENECK"},
+			Blame:  []string{"ERROR_ORIGIN"},
+			Gaps:   []string{"LATENCY_BOTTLENECK"},
+		},
+		{
+			Name:        "slow_dependency",
+			Description: "recommendation-service answers correctly but about 450ms late, slowing every page without errors.",
+			Topology: tracegen.Topology{
+				Root: "frontend",
+				Services: []tracegen.Service{
+					{Name: "frontend", Latency: tracegen.Latency{Distribution: "normal", MeanMs: 15, StdDevMs: 3, MinMs: 5}, Calls: []tracegen.Call{{Service: "catalog-service"}, {Service: "recommendation-service"}}},
+					{Name: "catalog-service", Latency: tracegen.Latency{Distribution: "lognormal", MeanMs: 25, StdDevMs: 8}},
+					{Name: "recommendation-service", Latency: tracegen.Latency{Distribution: "uniform", MinMs: 20, MaxMs: 40}},
+				},
+				Faults: []tracegen.Fault{{Kind: tracegen.FaultSlow, Service: "recommendation-service", DelayMs: 450}},
+			},
+			Expect: []string{"LATENCY_WARNING"},
+			Absent: []string{"ERROR_ORIGIN", "LATENCY_BOTTLENECK"},
+			Gaps:   []string{"LATENCY_WARNING"},
+		},
+		{
+			Name:        "partial_regional_outage",
+			Description: "the eu-west inventory replica refuses connections while us-east keeps serving; the frontend degrades gracefully.",
+			Topology: tracegen.Topology{
+				Root: "frontend",
+				Services: []tracegen.Service{
+					{Name: "frontend", Latency: tracegen.Latency{Distribution: "normal", MeanMs: 15, StdDevMs: 3, MinMs: 5}, Calls: []tracegen.Call{
+						{Service: "inventory-us-east", IgnoreErrors: true},
+						{Service: "inventory-eu-west", IgnoreErrors: true},
+					}},
+					{Name: "inventory-us-east", Latency: tracegen.Latency{Distribution: "lognormal", MeanMs: 30, StdDevMs: 10}, Attributes: map[string]any{"cloud.region": "us-east-1"}},
+					{Name: "inventory-eu-west", Latency: tracegen.Latency{MeanMs: 3}, Attributes: map[string]any{"cloud.region": "eu-west-1"}},
+				},
+				Faults: []tracegen.Fault{{Kind: tracegen.FaultErrorBurst, Service: "inventory-eu-west", Message: "connection refused: no healthy upstream in eu-west-1"}},
+			},
+			Expect: []string{"ERROR_ORIGIN"},
+			Absent: []string{"LATENCY_BOTTLENECK", "LATENCY_WARNING"},
+			Blame:  []string{"ERROR_ORIGIN"},
+		},
+	}
+}
@@ -0,0 +1,91 @@
+package scenario
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gigikoneti/tracemind/internal/tracegen"
+)
+
+func TestCatalogIsDetected(t *testing.T) {
+	results, err := Run(context.Background(), Catalog(), 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(Catalog()) {
+		t.Fatalf("got %d results, want %d", len(results), len(Catalog()))
+	}
+	for _, r := range results {
+		if !r.Detected {
+			t.Errorf("%s missed in %v: missing %v, unexpected %v, misattributed %v", r.Scenario, r.FailedCases, r.Missing, r.Unexpected, r.Misattributed)
+		}
+	}
+}
+
+func TestCatalogBlamesExpectedFacts(t *testing.T) {
+	for _, s := range Catalog() {
+		expected := make(map[string]bool)
+		for _, e := range s.Expect {
+			expected[e] = true
+		}
+		blamed := make(map[string]bool)
+		for _, b := range s.Blame {
+			blamed[b] = true
+			if !expected[b] {
+				t.Errorf("%s: blamed fact type %s is not expected", s.Name, b)
+			}
+		}
+		for _, g := range s.Gaps {
+			if !expected[g] || blamed[g] {
+				t.Errorf("%s: gap %s must be expected and not blamed", s.Name, g)
+			}
+		}
+	}
+}
+
+func TestRunChecksBlame(t *testing.T) {
+	// The slow checkout-service is the labeled root cause; the failing
+	// payment-service, whose error checkout-service ignores, is correctly
+	// its own ERROR_ORIGIN.
+	s := Scenario{
+		Name: "blame",
+		Topology: tracegen.Topology{
+			Root: "checkout-service",
+			Services: []tracegen.Service{
+				{Name: "checkout-service", Latency: tracegen.Latency{MeanMs: 20}, Calls: []tracegen.Call{{Service: "payment-service", IgnoreErrors: true}}},
+				{Name: "payment-service", Latency: tracegen.Latency{MeanMs: 10}},
+			},
+			Faults: []tracegen.Fault{
+				{Kind: tracegen.FaultSlow, Service: "checkout-service", DelayMs: 500},
+				{Kind: tracegen.FaultErrorBurst, Service: "payment-service"},
+			},
+		},
+		Expect: []string{"ERROR_ORIGIN"},
+		Blame:  []string{"ERROR_ORIGIN"},
+	}
+
+	results, err := Run(context.Background(), []Scenario{s}, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := results[0]
+	if r.Detected || len(r.FailedCases) != 3 {
+		t.Fatalf("want every trace missed, got %+v", r)
+	}
+	if len(r.Misattributed) != 1 || r.Misattributed[0] != "ERROR_ORIGIN" {
+		t.Errorf("misattributed = %v, want [ERROR_ORIGIN]", r.Misattributed)
+	}
+
+	s.Blame, s.Gaps = nil, []string{"ERROR_ORIGIN"}
+	results, err = Run(context.Background(), []Scenario{s}, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r = results[0]
+	if !r.Detected || len(r.Misattributed) != 0 {
+		t.Fatalf("a known gap failed the scenario: %+v", r)
+	}
+	if len(r.KnownGaps) != 1 || r.KnownGaps[0] != "ERROR_ORIGIN" {
+		t.Errorf("known gaps = %v, want [ERROR_ORIGIN]", r.KnownGaps)
+	}
+}
@@ -0,0 +1,172 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+	"github.com/google/uuid"
+)
+
+// Store keeps conversation sessions in memory and, when a directory is set,
+// persists each one as <id>.json so they survive restarts. Sessions expire
+// TTL after their last activity.
+type Store struct {
+	mu       sync.RWMutex
+	sessions map[string]*models.Session
+	dir      string
+	ttl      time.Duration
+}
+
+// NewStore loads any unexpired sessions found in dir. An empty dir keeps
+// sessions in memory only.
+func NewStore(dir string, ttl time.Duration) (*Store, error) {
+	s := &Store{
+		sessions: make(map[string]*models.Session),
+		dir:      dir,
+		ttl:      ttl,
+	}
+	if dir == "" {
+		return s, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create session directory: %w", err)
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("Skipping session file %s: %v", path, err)
+			continue
+		}
+		var sess models.Session
+		if err := json.Unmarshal(data, &sess); err != nil {
+			log.Printf("Skipping session file %s: %v", path, err)
+			continue
+		}
+		if sess.ExpiresAt.Before(now) {
+			os.Remove(path)
+			continue
+		}
+		s.sessions[sess.ID] = &sess
+	}
+	return s, nil
+}
+
+// Create starts a session for an analyzed trace.
+func (s *Store) Create(analysis models.TraceAnalysis, connectionID string) (models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	sess := &models.Session{
+		ID:           uuid.New().String(),
+		TraceID:      analysis.Trace.TraceID,
+		ConnectionID: connectionID,
+		Analysis:     analysis,
+		Messages:     []models.SessionMessage{},
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		ExpiresAt:    now.Add(s.ttl),
+	}
+	s.sessions[sess.ID] = sess
+	return *sess, s.save(sess)
+}
+
+// Get returns an unexpired session.
+func (s *Store) Get(id string) (models.Session, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sess, ok := s.sessions[id]
+	if !ok || sess.ExpiresAt.Before(time.Now()) {
+		return models.Session{}, false
+	}
+	out := *sess
+	out.Messages = append([]models.SessionMessage(nil), sess.Messages...)
+	return out, true
+}
+
+// Append adds turns to a session and extends its expiry.
+func (s *Store) Append(id string, messages ...models.SessionMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[id]
+	if !ok || sess.ExpiresAt.Before(time.Now()) {
+		return fmt.Errorf("session %s not found", id)
+	}
+	now := time.Now()
+	for _, m := range messages {
+		if m.Timestamp.IsZero() {
+			m.Timestamp = now
+		}
+		sess.Messages = append(sess.Messages, m)
+	}
+	sess.UpdatedAt = now
+	sess.ExpiresAt = now.Add(s.ttl)
+	return s.save(sess)
+}
+
+// Run removes expired sessions every interval until ctx is cancelled.
+func (s *Store) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweep()
+		}
+	}
+}
+
+func (s *Store) sweep() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, sess := range s.sessions {
+		if sess.ExpiresAt.Before(now) {
+			delete(s.sessions, id)
+			if s.dir != "" {
+				os.Remove(s.path(id))
+			}
+		}
+	}
+}
+
+// save writes a session atomically; callers hold the lock.
+func (s *Store) save(sess *models.Session) error {
+	if s.dir == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(sess)
+	if err != nil {
+		return err
+	}
+	tmp := s.path(sess.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to persist session: %w", err)
+	}
+	return os.Rename(tmp, s.path(sess.ID))
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, strings.ReplaceAll(id, string(filepath.Separator), "_")+".json")
+}
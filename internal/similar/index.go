@@ -0,0 +1,206 @@
+// Package similar keeps a local vector index of past trace analyses so new
+// explanations can reference incidents that looked alike.
+package similar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gigikoneti/tracemind/internal/llm"
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+// Record is one indexed analysis. Vector embeds Summary only, since a new
+// trace has no explanation yet to compare with.
+type Record struct {
+	TraceID string `json:"trace_id"`
+	// Fingerprint identifies the failure the analysis shows, so repeats
+	// of it are indexed once.
+	Fingerprint string    `json:"fingerprint,omitempty"`
+	Summary     string    `json:"summary"`
+	Explanation string    `json:"explanation"`
+	Vector      []float32 `json:"vector"`
+	AnalyzedAt  time.Time `json:"analyzed_at"`
+}
+
+// Index is a brute-force cosine similarity index, bounded to the most recent
+// maxEntries records and optionally persisted to a JSON file.
+type Index struct {
+	embedder   llm.Embedder
+	path       string
+	maxEntries int
+
+	mu      sync.RWMutex
+	records []Record
+}
+
+// NewIndex loads the records saved at path, if any. An empty path keeps the
+// index in memory only.
+func NewIndex(embedder llm.Embedder, path string, maxEntries int) (*Index, error) {
+	idx := &Index{
+		embedder:   embedder,
+		path:       path,
+		maxEntries: maxEntries,
+	}
+	if path == "" {
+		return idx, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read similarity index: %w", err)
+	}
+	if err := json.Unmarshal(data, &idx.records); err != nil {
+		return nil, fmt.Errorf("failed to parse similarity index: %w", err)
+	}
+	return idx, nil
+}
+
+// Add indexes an analysis with its explanation, replacing any earlier record
+// of the same trace. An analysis whose fingerprint matches another trace's
+// record is a repeat of an indexed incident and is skipped, so repeats do
+// not crowd out other incidents or change what similar traces retrieve.
+func (idx *Index) Add(ctx context.Context, analysis models.TraceAnalysis, explanation, fingerprint string) error {
+	if idx.indexed(analysis.Trace.TraceID, fingerprint) {
+		return nil
+	}
+
+	summary := Summarize(analysis)
+	vectors, err := idx.embedder.Embed(ctx, []string{summary})
+	if err != nil {
+		return err
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	records := idx.records[:0]
+	for _, r := range idx.records {
+		if r.TraceID != analysis.Trace.TraceID {
+			records = append(records, r)
+		}
+	}
+	records = append(records, Record{
+		TraceID:     analysis.Trace.TraceID,
+		Fingerprint: fingerprint,
+		Summary:     summary,
+		Explanation: explanation,
+		Vector:      vectors[0],
+		AnalyzedAt:  time.Now(),
+	})
+	if idx.maxEntries > 0 && len(records) > idx.maxEntries {
+		records = records[len(records)-idx.maxEntries:]
+	}
+	idx.records = records
+
+	return idx.save()
+}
+
+// Search returns up to k past analyses most similar to analysis with a
+// cosine similarity of at least minScore, best first. The trace itself is
+// excluded.
+func (idx *Index) Search(ctx context.Context, analysis models.TraceAnalysis, k int, minScore float64) ([]models.SimilarIncident, error) {
+	vectors, err := idx.embedder.Embed(ctx, []string{Summarize(analysis)})
+	if err != nil {
+		return nil, err
+	}
+	query := vectors[0]
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var matches []models.SimilarIncident
+	for _, r := range idx.records {
+		if r.TraceID == analysis.Trace.TraceID {
+			continue
+		}
+		score := cosine(query, r.Vector)
+		if score < minScore {
+			continue
+		}
+		matches = append(matches, models.SimilarIncident{
+			TraceID:     r.TraceID,
+			Summary:     r.Summary,
+			Explanation: r.Explanation,
+			Score:       score,
+			AnalyzedAt:  r.AnalyzedAt,
+		})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
+
+// indexed reports whether another trace with the fingerprint is indexed.
+func (idx *Index) indexed(traceID, fingerprint string) bool {
+	if fingerprint == "" {
+		return false
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	for _, r := range idx.records {
+		if r.Fingerprint == fingerprint && r.TraceID != traceID {
+			return true
+		}
+	}
+	return false
+}
+
+// Len reports how many analyses are indexed.
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.records)
+}
+
+// save writes the index atomically. The caller holds idx.mu.
+func (idx *Index) save() error {
+	if idx.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(idx.records)
+	if err != nil {
+		return err
+	}
+	tmp := idx.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, idx.path)
+}
+
+// cosine returns 0 for vectors of different dimensions, which happens when
+// the embedding model changes under a persisted index.
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,69 @@
+package similar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+// maxErrorLines bounds the error section so large failing traces do not
+// dominate the embedding.
+const maxErrorLines = 10
+
+// Summarize describes an analysis in the terms incidents are compared by:
+// the services involved, what failed, the facts, and where time was spent.
+// IDs and timestamps are left out so unrelated traces do not match on them.
+func Summarize(analysis models.TraceAnalysis) string {
+	var sb strings.Builder
+
+	seen := make(map[string]bool)
+	var services []string
+	var errs []string
+	var slowest *models.Span
+	for i := range analysis.Trace.Spans {
+		s := &analysis.Trace.Spans[i]
+		if !seen[s.Name] {
+			seen[s.Name] = true
+			services = append(services, s.Name)
+		}
+		if s.Status.Code == "ERROR" {
+			line := s.Name
+			if s.Status.Message != "" {
+				line += ": " + s.Status.Message
+			}
+			errs = append(errs, line)
+		}
+		if slowest == nil || s.LatencyMs() > slowest.LatencyMs() {
+			slowest = s
+		}
+	}
+	sort.Strings(services)
+
+	sb.WriteString(fmt.Sprintf("Operations: %s\n", strings.Join(services, ", ")))
+
+	if len(errs) > 0 {
+		sb.WriteString("Errors:\n")
+		for i, e := range errs {
+			if i == maxErrorLines {
+				sb.WriteString(fmt.Sprintf("- ... %d more\n", len(errs)-i))
+				break
+			}
+			sb.WriteString("- " + e + "\n")
+		}
+	}
+
+	if len(analysis.SymbolicFacts) > 0 {
+		sb.WriteString("Facts:\n")
+		for _, f := range analysis.SymbolicFacts {
+			sb.WriteString(fmt.Sprintf("- [%s] %s %s: %s\n", f.Severity, f.Type, f.Service, f.Description))
+		}
+	}
+
+	if slowest != nil {
+		sb.WriteString(fmt.Sprintf("Slowest operation: %s (%.0fms)\n", slowest.Name, slowest.LatencyMs()))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,409 @@
+package tracegen
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gigikoneti/tracemind/internal/eval"
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+// Defaults for fault and call fields left unset.
+const (
+	defaultTimeoutMs   = 1000
+	defaultDelayMs     = 1000
+	defaultAttempts    = 3
+	defaultNPlusOne    = 25
+	defaultBurstLength = 5
+	retryBackoff       = 50 * time.Millisecond
+)
+
+// Generator produces traces from a topology. The same topology and seed
+// always produce the same traces.
+type Generator struct {
+	topology Topology
+	services map[string]*Service
+	seed     int64
+	rng      *rand.Rand
+	// burst counts the traces left in each fault's current error burst.
+	burst []int
+	count int
+}
+
+func NewGenerator(t Topology, seed int64) (*Generator, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	g := &Generator{
+		topology: t,
+		services: make(map[string]*Service, len(t.Services)),
+		seed:     seed,
+		rng:      rand.New(rand.NewSource(seed)),
+		burst:    make([]int, len(t.Faults)),
+	}
+	for i := range g.topology.Services {
+		g.services[g.topology.Services[i].Name] = &g.topology.Services[i]
+	}
+	return g, nil
+}
+
+// Generate returns n traces starting interval apart from start, as a
+// dataset that can be saved under DATASET_DIR.
+func (g *Generator) Generate(n int, start time.Time, interval time.Duration) eval.Dataset {
+	d := eval.Dataset{
+		Name:        g.topology.Name,
+		Description: fmt.Sprintf("Generated from topology %q with seed %d.", g.topology.Name, g.seed),
+		Cases:       make([]eval.Case, 0, n),
+	}
+	for i := 0; i < n; i++ {
+		d.Cases = append(d.Cases, g.Next(start.Add(time.Duration(i)*interval)))
+	}
+	return d
+}
+
+// Next generates one trace starting at start. Traces hit by a fault are
+// labeled with it as the root cause; others are unlabeled.
+func (g *Generator) Next(start time.Time) eval.Case {
+	g.count++
+	t := &traceState{traceID: g.hexID(2), culprits: make(map[*Fault][]string)}
+	for i := range g.topology.Faults {
+		if g.triggered(i) {
+			t.active = append(t.active, &g.topology.Faults[i])
+		}
+	}
+	g.span(t, request{service: g.topology.Root, start: start})
+
+	name := g.topology.Name
+	if name == "" {
+		name = "trace"
+	}
+	return eval.Case{
+		ID:     fmt.Sprintf("%s-%04d", name, g.count),
+		Trace:  models.Trace{TraceID: t.traceID, Spans: t.spans},
+		Labels: t.labels(),
+	}
+}
+
+// triggered reports whether a fault hits the next trace. An error burst,
+// once started, hits the following traces too.
+func (g *Generator) triggered(i int) bool {
+	f := &g.topology.Faults[i]
+	if g.burst[i] > 0 {
+		g.burst[i]--
+		return true
+	}
+	p := 1.0
+	if f.Probability != nil {
+		p = *f.Probability
+	}
+	if g.rng.Float64() >= p {
+		return false
+	}
+	if f.Kind == FaultErrorBurst {
+		g.burst[i] = orInt(f.BurstLength, defaultBurstLength) - 1
+	}
+	return true
+}
+
+// traceState is a trace being generated.
+type traceState struct {
+	traceID string
+	spans   []models.Span
+	active  []*Fault
+	// culprits are the spans each active fault showed up in.
+	culprits map[*Fault][]string
+}
+
+func (t *traceState) fault(kind, service string) *Fault {
+	for _, f := range t.active {
+		if f.Kind == kind && f.Service == service {
+			return f
+		}
+	}
+	return nil
+}
+
+func (t *traceState) blame(f *Fault, spanID string) {
+	t.culprits[f] = append(t.culprits[f], spanID)
+}
+
+// request is one call to a service.
+type request struct {
+	service  string
+	parentID string
+	start    time.Time
+	// timeoutMs is the caller's deadline.
+	timeoutMs float64
+	// deadline is when an ancestor that timed out gave up, after which
+	// no work is done; zero if none did.
+	deadline time.Time
+	// failWith forces the span to fail, for retried attempts.
+	failWith string
+	attempt  int
+}
+
+// span generates the span of a request and those of its calls, returning
+// its ID, when it ends and its error message, if it failed.
+func (g *Generator) span(t *traceState, req request) (string, time.Time, string) {
+	svc := g.services[req.service]
+	id := g.hexID(1)
+	self := g.sample(svc.Latency)
+	if f := t.fault(FaultSlow, svc.Name); f != nil {
+		self += orFloat(f.DelayMs, defaultDelayMs)
+		t.blame(f, id)
+	}
+
+	// Reserve the span's place so parents precede their children.
+	index := len(t.spans)
+	t.spans = append(t.spans, models.Span{})
+
+	// A service that times out gives up at the caller's deadline, and so
+	// do its calls.
+	deadline := req.deadline
+	timeout := t.fault(FaultTimeout, svc.Name)
+	if timeout != nil {
+		if d := req.start.Add(millis(orFloat(req.timeoutMs, defaultTimeoutMs))); deadline.IsZero() || d.Before(deadline) {
+			deadline = d
+		}
+	}
+
+	var errMsg string
+	cursor := req.start.Add(millis(self / 2))
+	for _, c := range svc.Calls {
+		count := orInt(c.Count, 1)
+		if f := t.fault(FaultNPlusOne, c.Service); f != nil {
+			count = orInt(f.Count, defaultNPlusOne)
+			t.blame(f, id)
+		}
+		end := cursor
+		for i := 0; i < count; i++ {
+			at := end
+			if c.Parallel {
+				at = cursor
+			}
+			if !deadline.IsZero() && !at.Before(deadline) {
+				break
+			}
+			callEnd, callErr := g.call(t, c, id, at, deadline)
+			if callEnd.After(end) {
+				end = callEnd
+			}
+			if callErr != "" && !c.IgnoreErrors && errMsg == "" {
+				errMsg = fmt.Sprintf("call to %s failed: %s", c.Service, callErr)
+			}
+		}
+		cursor = end
+	}
+	end := cursor.Add(millis(self / 2))
+	if !deadline.IsZero() && end.After(deadline) {
+		end = deadline
+	}
+
+	status := 200
+	if timeout != nil {
+		end = deadline
+		errMsg, status = orString(timeout.Message, "context deadline exceeded"), 504
+		t.blame(timeout, id)
+	}
+	if f := t.fault(FaultErrorBurst, svc.Name); f != nil {
+		errMsg, status = orString(f.Message, "internal server error"), 500
+		t.blame(f, id)
+	}
+	if req.failWith != "" {
+		errMsg, status = req.failWith, 503
+	}
+	if errMsg != "" && status == 200 {
+		status = 500
+	}
+
+	span := models.Span{
+		SpanID:       id,
+		TraceID:      t.traceID,
+		ParentSpanID: req.parentID,
+		Name:         svc.Name,
+		Kind:         "SERVER",
+		StartTime:    req.start,
+		EndTime:      end,
+		Attributes: []models.Attribute{
+			{Key: "service.name", Value: svc.Name},
+			{Key: "http.status_code", Value: status},
+		},
+		Status: models.Status{Code: "OK"},
+	}
+	if errMsg != "" {
+		span.Status = models.Status{Code: "ERROR", Message: errMsg}
+	}
+	if req.attempt > 0 {
+		span.Attributes = append(span.Attributes, models.Attribute{Key: "retry.attempt", Value: req.attempt})
+	}
+	keys := make([]string, 0, len(svc.Attributes))
+	for k := range svc.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		span.Attributes = append(span.Attributes, models.Attribute{Key: k, Value: svc.Attributes[k]})
+	}
+	t.spans[index] = span
+	return id, end, errMsg
+}
+
+// call makes one call, retrying it when a retry fault is active until
+// deadline, and returns when the caller got its answer and the error, if
+// it failed.
+func (g *Generator) call(t *traceState, c Call, parentID string, at, deadline time.Time) (time.Time, string) {
+	f := t.fault(FaultRetry, c.Service)
+	attempts := 1
+	if f != nil {
+		attempts = orInt(f.Attempts, defaultAttempts)
+	}
+
+	var end time.Time
+	var errMsg string
+	for a := 1; a <= attempts; a++ {
+		req := request{service: c.Service, parentID: parentID, start: at, timeoutMs: c.TimeoutMs, deadline: deadline}
+		if f != nil {
+			req.attempt = a
+			if a < attempts {
+				req.failWith = orString(f.Message, "connection reset by peer")
+			}
+		}
+		var id string
+		id, end, errMsg = g.span(t, req)
+		if req.failWith != "" {
+			t.blame(f, id)
+		}
+		if errMsg == "" {
+			break
+		}
+		at = end.Add(retryBackoff << (a - 1))
+		if !deadline.IsZero() && !at.Before(deadline) {
+			break
+		}
+	}
+	return end, errMsg
+}
+
+// labels derives the ground truth: the first fault that showed up is the
+// root cause, and the services above its spans are affected.
+func (t *traceState) labels() *eval.Labels {
+	spans := make(map[string]*models.Span, len(t.spans))
+	for i := range t.spans {
+		spans[t.spans[i].SpanID] = &t.spans[i]
+	}
+
+	var labels *eval.Labels
+	var notes []string
+	affected := make(map[string]bool)
+	for _, f := range t.active {
+		ids := t.culprits[f]
+		if len(ids) == 0 {
+			continue
+		}
+		cause := spans[ids[0]].Name
+		notes = append(notes, fmt.Sprintf("%s in %s", f.Kind, cause))
+		if labels == nil {
+			labels = &eval.Labels{RootCauseService: cause, RootCauseSpanIDs: unique(ids)}
+		} else {
+			affected[cause] = true
+		}
+		if f.Kind == FaultNPlusOne {
+			affected[f.Service] = true
+		}
+		for _, id := range ids {
+			for s := spans[spans[id].ParentSpanID]; s != nil; s = spans[s.ParentSpanID] {
+				affected[s.Name] = true
+			}
+		}
+	}
+	if labels == nil {
+		return nil
+	}
+
+	delete(affected, labels.RootCauseService)
+	for s := range affected {
+		labels.AffectedServices = append(labels.AffectedServices, s)
+	}
+	sort.Strings(labels.AffectedServices)
+	labels.Notes = "Injected: " + strings.Join(notes, "; ")
+	return labels
+}
+
+// sample draws a latency in milliseconds.
+func (g *Generator) sample(l Latency) float64 {
+	var v float64
+	switch l.Distribution {
+	case "uniform":
+		v = l.MinMs + g.rng.Float64()*(l.MaxMs-l.MinMs)
+	case "normal":
+		v = l.MeanMs + g.rng.NormFloat64()*l.StdDevMs
+	case "lognormal":
+		// Parameters giving the distribution the configured mean and
+		// standard deviation.
+		if l.MeanMs > 0 {
+			sigma2 := math.Log(1 + (l.StdDevMs*l.StdDevMs)/(l.MeanMs*l.MeanMs))
+			mu := math.Log(l.MeanMs) - sigma2/2
+			v = math.Exp(mu + math.Sqrt(sigma2)*g.rng.NormFloat64())
+		}
+	default:
+		v = l.MeanMs
+	}
+	if l.MinMs > 0 && v < l.MinMs {
+		v = l.MinMs
+	}
+	if l.MaxMs > 0 && v > l.MaxMs {
+		v = l.MaxMs
+	}
+	return math.Max(v, 0)
+}
+
+// hexID returns a random ID of n 8-byte words in hex: 1 for a span ID, 2
+// for a trace ID.
+func (g *Generator) hexID(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "%016x", g.rng.Uint64())
+	}
+	return b.String()
+}
+
+func millis(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+func unique(ids []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func orInt(v, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
+func orFloat(v, def float64) float64 {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
+func orString(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,140 @@
+package tracegen
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gigikoneti/tracemind/internal/eval"
+	"github.com/gigikoneti/tracemind/internal/models"
+)
+
+// ReplayResult is the outcome of sending one trace.
+type ReplayResult struct {
+	CaseID  string
+	TraceID string
+	Status  int
+	Latency time.Duration
+	Err     error
+}
+
+// Replay posts each case's trace to url, such as a running server's
+// /api/analyze, starting one every interval with at most concurrency in
+// flight. Traces are shifted to start when they are sent so the server
+// sees them as live. An SSE response is read to the end and fails if it
+// carries an error event. onResult is called as each request completes.
+func Replay(ctx context.Context, client *http.Client, url string, cases []eval.Case, interval time.Duration, concurrency int, onResult func(ReplayResult)) error {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	var ticker *time.Ticker
+	if interval > 0 {
+		ticker = time.NewTicker(interval)
+		defer ticker.Stop()
+	}
+
+	for i, c := range cases {
+		if i > 0 && ticker != nil {
+			select {
+			case <-ctx.Done():
+				wg.Wait()
+				return ctx.Err()
+			case <-ticker.C:
+			}
+		}
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(c eval.Case) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result := send(ctx, client, url, Shift(c.Trace, time.Now()))
+			result.CaseID = c.ID
+			mu.Lock()
+			defer mu.Unlock()
+			onResult(result)
+		}(c)
+	}
+	wg.Wait()
+	return nil
+}
+
+func send(ctx context.Context, client *http.Client, url string, trace models.Trace) ReplayResult {
+	result := ReplayResult{TraceID: trace.TraceID}
+	body, err := json.Marshal(trace)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	start := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer resp.Body.Close()
+	result.Status = resp.StatusCode
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	var first string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if first == "" {
+			first = line
+		}
+		if line == "event: error" && scanner.Scan() && result.Err == nil {
+			result.Err = fmt.Errorf("server error: %s", strings.TrimPrefix(scanner.Text(), "data: "))
+		}
+	}
+	result.Latency = time.Since(start)
+	if err := scanner.Err(); err != nil && result.Err == nil {
+		result.Err = err
+	}
+	if resp.StatusCode != http.StatusOK && result.Err == nil {
+		result.Err = fmt.Errorf("server returned %s: %s", resp.Status, first)
+	}
+	return result
+}
+
+// Shift moves every span of a trace by the same amount so that it starts
+// at start.
+func Shift(trace models.Trace, start time.Time) models.Trace {
+	if len(trace.Spans) == 0 {
+		return trace
+	}
+	first, _ := trace.TimeWindow()
+	offset := start.Sub(first)
+
+	shifted := trace
+	shifted.Spans = make([]models.Span, len(trace.Spans))
+	for i, s := range trace.Spans {
+		s.StartTime = s.StartTime.Add(offset)
+		s.EndTime = s.EndTime.Add(offset)
+		shifted.Spans[i] = s
+	}
+	return shifted
+}
@@ -0,0 +1,186 @@
+// Package tracegen generates realistic traces from a topology description,
+// with injected faults and their ground truth, for evaluations and demos.
+package tracegen
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fault kinds.
+const (
+	// FaultTimeout makes a service's spans run until the caller's deadline
+	// and fail with "context deadline exceeded".
+	FaultTimeout = "timeout"
+	// FaultErrorBurst makes a service fail for several traces in a row.
+	FaultErrorBurst = "error_burst"
+	// FaultRetry makes calls to a service fail and be retried until the
+	// last attempt succeeds.
+	FaultRetry = "retry"
+	// FaultNPlusOne makes every caller of a service call it once per item
+	// instead of once per request.
+	FaultNPlusOne = "n_plus_one"
+	// FaultSlow adds latency to a service.
+	FaultSlow = "slow"
+)
+
+// Topology describes a system: its services, who calls whom, how long each
+// takes and which faults to inject. It is read from YAML or JSON.
+type Topology struct {
+	Name string `json:"name" yaml:"name"`
+	// Root is the entry service; it defaults to the first one.
+	Root     string    `json:"root,omitempty" yaml:"root"`
+	Services []Service `json:"services" yaml:"services"`
+	Faults   []Fault   `json:"faults,omitempty" yaml:"faults"`
+}
+
+type Service struct {
+	Name string `json:"name" yaml:"name"`
+	// Latency is the service's own work, excluding its calls.
+	Latency    Latency        `json:"latency" yaml:"latency"`
+	Calls      []Call         `json:"calls,omitempty" yaml:"calls"`
+	Attributes map[string]any `json:"attributes,omitempty" yaml:"attributes"`
+}
+
+// Call is a call from one service to another, made after half of the
+// caller's own work.
+type Call struct {
+	Service string `json:"service" yaml:"service"`
+	// Count is how many times the call is made per request, default 1.
+	Count int `json:"count,omitempty" yaml:"count"`
+	// Parallel makes repeated calls concurrent instead of sequential.
+	Parallel bool `json:"parallel,omitempty" yaml:"parallel"`
+	// TimeoutMs is the caller's deadline, used by timeout faults; 0 means 1000.
+	TimeoutMs float64 `json:"timeout_ms,omitempty" yaml:"timeout_ms"`
+	// IgnoreErrors keeps a failed call from failing the caller.
+	IgnoreErrors bool `json:"ignore_errors,omitempty" yaml:"ignore_errors"`
+}
+
+// Latency is a latency distribution in milliseconds: constant (MeanMs),
+// uniform (MinMs to MaxMs), normal or lognormal (MeanMs, StdDevMs).
+// Samples are clamped to [MinMs, MaxMs] when those are set.
+type Latency struct {
+	Distribution string  `json:"distribution,omitempty" yaml:"distribution"`
+	MeanMs       float64 `json:"mean_ms,omitempty" yaml:"mean_ms"`
+	StdDevMs     float64 `json:"stddev_ms,omitempty" yaml:"stddev_ms"`
+	MinMs        float64 `json:"min_ms,omitempty" yaml:"min_ms"`
+	MaxMs        float64 `json:"max_ms,omitempty" yaml:"max_ms"`
+}
+
+// Fault is a failure injected into a service.
+type Fault struct {
+	Kind    string `json:"kind" yaml:"kind"`
+	Service string `json:"service" yaml:"service"`
+	// Probability is the chance a trace is affected, default 1; 0
+	// disables the fault.
+	Probability *float64 `json:"probability,omitempty" yaml:"probability"`
+	// Message is the error recorded on failing spans.
+	Message string `json:"message,omitempty" yaml:"message"`
+	// DelayMs is the latency a slow fault adds, default 1000.
+	DelayMs float64 `json:"delay_ms,omitempty" yaml:"delay_ms"`
+	// Attempts is the number of calls a retry fault makes, default 3.
+	Attempts int `json:"attempts,omitempty" yaml:"attempts"`
+	// Count is the calls per request of an N+1 fault, default 25.
+	Count int `json:"count,omitempty" yaml:"count"`
+	// BurstLength is the traces an error burst lasts, default 5.
+	BurstLength int `json:"burst_length,omitempty" yaml:"burst_length"`
+}
+
+// LoadTopology reads a YAML or JSON topology file.
+func LoadTopology(path string) (Topology, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Topology{}, err
+	}
+	var t Topology
+	if err := yaml.Unmarshal(data, &t); err != nil {
+		return Topology{}, fmt.Errorf("failed to parse topology %s: %w", path, err)
+	}
+	if err := t.Validate(); err != nil {
+		return Topology{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
+
+// Validate checks that calls and faults name known services, the call
+// graph has no cycles and every faulty service is reachable from the root.
+func (t *Topology) Validate() error {
+	if len(t.Services) == 0 {
+		return errors.New("topology has no services")
+	}
+	services := make(map[string]*Service, len(t.Services))
+	for i := range t.Services {
+		s := &t.Services[i]
+		if s.Name == "" {
+			return fmt.Errorf("service %d has no name", i)
+		}
+		if services[s.Name] != nil {
+			return fmt.Errorf("duplicate service %q", s.Name)
+		}
+		switch s.Latency.Distribution {
+		case "", "constant", "uniform", "normal", "lognormal":
+		default:
+			return fmt.Errorf("service %q: unknown latency distribution %q", s.Name, s.Latency.Distribution)
+		}
+		services[s.Name] = s
+	}
+	for _, s := range t.Services {
+		for _, c := range s.Calls {
+			if services[c.Service] == nil {
+				return fmt.Errorf("service %q calls unknown service %q", s.Name, c.Service)
+			}
+		}
+	}
+	if t.Root == "" {
+		t.Root = t.Services[0].Name
+	}
+	if services[t.Root] == nil {
+		return fmt.Errorf("unknown root service %q", t.Root)
+	}
+
+	// Walk the call graph from the root, failing on a cycle.
+	reachable := make(map[string]bool)
+	onPath := make(map[string]bool)
+	var walk func(name string) error
+	walk = func(name string) error {
+		if onPath[name] {
+			return fmt.Errorf("call graph has a cycle through %q", name)
+		}
+		if reachable[name] {
+			return nil
+		}
+		reachable[name] = true
+		onPath[name] = true
+		defer delete(onPath, name)
+		for _, c := range services[name].Calls {
+			if err := walk(c.Service); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(t.Root); err != nil {
+		return err
+	}
+
+	for i, f := range t.Faults {
+		switch f.Kind {
+		case FaultTimeout, FaultErrorBurst, FaultRetry, FaultNPlusOne, FaultSlow:
+		default:
+			return fmt.Errorf("fault %d: unknown kind %q", i, f.Kind)
+		}
+		if !reachable[f.Service] {
+			return fmt.Errorf("fault %d: service %q is not called from %q", i, f.Service, t.Root)
+		}
+		if f.Service == t.Root && (f.Kind == FaultRetry || f.Kind == FaultNPlusOne || f.Kind == FaultTimeout) {
+			return fmt.Errorf("fault %d: %s needs a service with a caller", i, f.Kind)
+		}
+		if f.Probability != nil && (*f.Probability < 0 || *f.Probability > 1) {
+			return fmt.Errorf("fault %d: probability must be between 0 and 1", i)
+		}
+	}
+	return nil
+}
@@ -1,16 +1,41 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
+	"github.com/gigikoneti/tracemind/internal/alerting"
+	"github.com/gigikoneti/tracemind/internal/analyzer"
+	"github.com/gigikoneti/tracemind/internal/cache"
+	"github.com/gigikoneti/tracemind/internal/eval"
+	"github.com/gigikoneti/tracemind/internal/experiment"
 	"github.com/gigikoneti/tracemind/internal/handlers"
+	"github.com/gigikoneti/tracemind/internal/incident"
+	"github.com/gigikoneti/tracemind/internal/k8s"
 	"github.com/gigikoneti/tracemind/internal/llm"
 	"github.com/gigikoneti/tracemind/internal/memory"
+	"github.com/gigikoneti/tracemind/internal/notify"
+	"github.com/gigikoneti/tracemind/internal/otlp"
+	"github.com/gigikoneti/tracemind/internal/prompt"
+	"github.com/gigikoneti/tracemind/internal/runbook"
+	"github.com/gigikoneti/tracemind/internal/session"
+	"github.com/gigikoneti/tracemind/internal/similar"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		if run, ok := commands[os.Args[1]]; ok {
+			if err := run(os.Args[2:]); err != nil {
+				log.Fatalf("%s: %v", os.Args[1], err)
+			}
+			return
+		}
+	}
+
 	modelName := os.Getenv("OLLAMA_MODEL")
 	if modelName == "" {
 		modelName = "mistral"
@@ -21,19 +46,217 @@ func main() {
 		log.Fatalf("Failed to initialize LLM engine: %v", err)
 	}
 
+	if path := os.Getenv("LLM_PRICE_TABLE"); path != "" {
+		if err := llm.LoadPriceTable(path); err != nil {
+			log.Fatalf("Failed to load price table: %v", err)
+		}
+	}
+	usage := llm.NewUsageTracker()
+	llm.LoadTokenizer()
+
 	store := memory.NewStore(50)
+	store.SetMetricRetention(envDuration("METRIC_RETENTION", time.Hour))
+	store.SetMaxMetricSeries(envInt("METRIC_MAX_SERIES", 10000))
+
+	pipeline := &analyzer.Pipeline{
+		Memory:       store,
+		ChangeWindow: envDuration("CHANGE_CORRELATION_WINDOW", 30*time.Minute),
+	}
+	if path := os.Getenv("K8S_KUBECONFIG"); path != "" {
+		client, err := k8s.NewClientFromKubeconfig(path)
+		if err != nil {
+			log.Fatalf("Failed to load kubeconfig: %v", err)
+		}
+		pipeline.K8s = &k8s.Enricher{
+			Client:       client,
+			RecentWindow: envDuration("K8S_RECENT_WINDOW", 15*time.Minute),
+			Timeout:      5 * time.Second,
+		}
+	}
+
+	if provider := os.Getenv("EMBEDDING_PROVIDER"); provider != "" {
+		model := os.Getenv("EMBEDDING_MODEL")
+		if model == "" {
+			model = "nomic-embed-text"
+			if provider == string(llm.ProviderOpenAI) {
+				model = "text-embedding-3-small"
+			}
+		}
+		embedder, err := llm.NewEmbedder(llm.AIProvider(provider), os.Getenv("EMBEDDING_ENDPOINT"), model, os.Getenv("EMBEDDING_API_KEY"))
+		if err != nil {
+			log.Fatalf("Failed to initialize embedder: %v", err)
+		}
+		indexPath := os.Getenv("SIMILAR_INDEX_PATH")
+		if indexPath == "" {
+			indexPath = "data/similar.json"
+		}
+		pipeline.Similar, err = similar.NewIndex(embedder, indexPath, envInt("SIMILAR_MAX_ENTRIES", 1000))
+		if err != nil {
+			log.Fatalf("Failed to open similarity index: %v", err)
+		}
+		pipeline.SimilarTopK = envInt("SIMILAR_TOP_K", 3)
+		pipeline.SimilarMinScore = envFloat("SIMILAR_MIN_SCORE", 0.75)
+	}
+
+	pipeline.Runbooks, err = runbook.NewStore(os.Getenv("RUNBOOK_DIR"))
+	if err != nil {
+		log.Fatalf("Failed to load runbooks: %v", err)
+	}
+	pipeline.RunbookTopK = envInt("RUNBOOK_TOP_K", 3)
+
+	promptDir := os.Getenv("PROMPT_DIR")
+	if promptDir == "" {
+		promptDir = "data/prompts"
+	}
+	if err := prompt.LoadDir(promptDir); err != nil {
+		log.Fatalf("Failed to load prompt templates: %v", err)
+	}
+
+	var notifier *notify.Notifier
+	if path := os.Getenv("NOTIFY_CONFIG"); path != "" {
+		notifier, err = notify.LoadConfig(path)
+		if err != nil {
+			log.Fatalf("Failed to load notifier config: %v", err)
+		}
+	}
+
+	connectionStore := handlers.NewConnectionStore()
+
+	sessionDir := os.Getenv("SESSION_DIR")
+	if sessionDir == "" {
+		sessionDir = "data/sessions"
+	}
+	sessions, err := session.NewStore(sessionDir, envDuration("SESSION_TTL", 24*time.Hour))
+	if err != nil {
+		log.Fatalf("Failed to open session store: %v", err)
+	}
+	go sessions.Run(context.Background(), time.Minute)
+
+	evaluationDir := os.Getenv("EVALUATION_DIR")
+	if evaluationDir == "" {
+		evaluationDir = "data/evaluations"
+	}
+	evaluations, err := eval.NewStore(evaluationDir)
+	if err != nil {
+		log.Fatalf("Failed to open evaluation store: %v", err)
+	}
+
+	var analysisCache *cache.Cache
+	if ttl := envDuration("ANALYSIS_CACHE_TTL", 10*time.Minute); ttl > 0 {
+		analysisCache = cache.New(ttl, envInt("ANALYSIS_CACHE_MAX_ENTRIES", 500), envInt("ANALYSIS_CACHE_MAX_BYTES", 64<<20))
+	}
 
 	traceHandler := &handlers.TraceHandler{
-		Engine: engine,
+		Engine:      engine,
+		Connections: connectionStore,
+		Memory:      store,
+		Pipeline:    pipeline,
+		Notifier:    notifier,
+		Usage:       usage,
+		Sessions:    sessions,
+		Cache:       analysisCache,
+		Evaluations: evaluations,
+
+		MapReduceConcurrency: envInt("MAPREDUCE_CONCURRENCY", llm.DefaultMapReduceConcurrency),
+		AgentSteps:           envInt("AGENT_MAX_STEPS", llm.DefaultAgentSteps),
+	}
+
+	incidentStore := incident.NewStore()
+	detectorConfig := incident.DefaultConfig()
+	detectorConfig.ErrorRateThreshold = envFloat("INCIDENT_ERROR_RATE_THRESHOLD", detectorConfig.ErrorRateThreshold)
+	detectorConfig.LatencyThresholdMs = envFloat("INCIDENT_LATENCY_THRESHOLD_MS", detectorConfig.LatencyThresholdMs)
+	detectorConfig.Interval = envDuration("INCIDENT_CHECK_INTERVAL", detectorConfig.Interval)
+	detector := &incident.Detector{
+		Memory:   store,
+		Pipeline: pipeline,
+		Engine:   engine,
+		Store:    incidentStore,
+		Config:   detectorConfig,
+		Notifier: notifier,
+		Usage:    usage,
+	}
+	go detector.Run(context.Background())
+
+	incidentHandler := &handlers.IncidentHandler{
+		Store: incidentStore,
+	}
+
+	metricsHandler := &handlers.MetricsHandler{
+		Memory:     store,
+		Cumulative: otlp.NewCumulative(),
+	}
+
+	changeHandler := &handlers.ChangeHandler{
 		Memory: store,
 	}
 
-	connectionStore := handlers.NewConnectionStore()
+	alertHandler := &handlers.AlertHandler{
+		Responder: &alerting.Responder{
+			Memory:         store,
+			Pipeline:       pipeline,
+			Engine:         engine,
+			Store:          alerting.NewStore(),
+			Notifier:       notifier,
+			Lookback:       envDuration("ALERT_LOOKBACK", 5*time.Minute),
+			ExplainTimeout: 5 * time.Minute,
+			RetryInterval:  envDuration("ALERT_RETRY_INTERVAL", time.Minute),
+			Usage:          usage,
+		},
+	}
+
+	sessionHandler := &handlers.SessionHandler{
+		Sessions:    sessions,
+		Engine:      engine,
+		Connections: connectionStore,
+		Usage:       usage,
+	}
+
+	cacheHandler := &handlers.CacheHandler{
+		Cache: analysisCache,
+	}
+
+	similarHandler := &handlers.SimilarHandler{
+		Pipeline: pipeline,
+	}
+
+	runbookHandler := &handlers.RunbookHandler{
+		Store: pipeline.Runbooks,
+	}
+
+	promptHandler := &handlers.PromptHandler{}
+
+	evaluationHandler := &handlers.EvaluationHandler{
+		Store: evaluations,
+	}
+
+	datasets := make(map[string]*eval.Dataset)
+	if dir := os.Getenv("DATASET_DIR"); dir != "" {
+		datasets, err = eval.LoadDir(dir)
+		if err != nil {
+			log.Fatalf("Failed to load evaluation datasets: %v", err)
+		}
+	}
+
+	runner := experiment.NewRunner(pipeline, usage, envInt("EXPERIMENT_CONCURRENCY", 4))
+	runner.Retain = envInt("EXPERIMENT_RETAIN", experiment.DefaultRetain)
+	experimentHandler := &handlers.ExperimentHandler{
+		Runner:      runner,
+		Engine:      engine,
+		Connections: connectionStore,
+		Datasets:    datasets,
+	}
+
+	usageHandler := &handlers.UsageHandler{
+		Tracker: usage,
+	}
+
 	connectionHandler := &handlers.ConnectionHandler{
 		Store: connectionStore,
+		Usage: usage,
 	}
 	designHandler := &handlers.AIDesignHandler{
 		ConnectionStore: connectionStore,
+		Usage:           usage,
 	}
 
 	// Common CORS middleware for all routes
@@ -52,6 +275,53 @@ func main() {
 	// Trace analysis routes (existing)
 	http.HandleFunc("/api/analyze", withCORS(traceHandler.AnalyzeTraceStream))
 	http.HandleFunc("/api/evaluate", withCORS(traceHandler.Evaluate))
+	http.HandleFunc("/api/evaluations", withCORS(evaluationHandler.Evaluations))
+
+	// Similar incident search
+	if pipeline.Similar != nil {
+		http.HandleFunc("/api/similar", withCORS(similarHandler.Similar))
+	}
+
+	// Runbook knowledge base routes
+	http.HandleFunc("/api/runbooks", withCORS(runbookHandler.Runbooks))
+
+	// Prompt template routes
+	http.HandleFunc("/api/prompts", withCORS(promptHandler.Prompts))
+	http.HandleFunc("/api/prompts/{name}", withCORS(promptHandler.Versions))
+	http.HandleFunc("/api/prompts/{name}/activate", withCORS(promptHandler.Activate))
+
+	// Prompt experiment routes
+	http.HandleFunc("/api/experiments", withCORS(experimentHandler.Experiments))
+	http.HandleFunc("/api/experiments/{id}", withCORS(experimentHandler.Experiment))
+	http.HandleFunc("/api/experiments/{id}/report", withCORS(experimentHandler.Report))
+	http.HandleFunc("/api/experiments/{id}/cancel", withCORS(experimentHandler.Cancel))
+	http.HandleFunc("/api/datasets", withCORS(experimentHandler.ListDatasets))
+
+	// Follow-up conversation routes
+	http.HandleFunc("/api/sessions/{id}", withCORS(sessionHandler.Session))
+	http.HandleFunc("/api/sessions/{id}/messages", withCORS(sessionHandler.Messages))
+
+	// Incident routes
+	http.HandleFunc("/api/incidents", withCORS(incidentHandler.ListIncidents))
+	http.HandleFunc("/api/incidents/get", withCORS(incidentHandler.GetIncident))
+	http.HandleFunc("/api/incidents/resolve", withCORS(incidentHandler.ResolveIncident))
+
+	// Metrics ingestion routes
+	http.HandleFunc("/api/metrics/otlp", withCORS(metricsHandler.IngestOTLP))
+	http.HandleFunc("/api/metrics", withCORS(metricsHandler.ListSeries))
+
+	// Change event routes
+	http.HandleFunc("/api/changes", withCORS(changeHandler.Changes))
+
+	// Alertmanager receiver routes
+	http.HandleFunc("/api/alerts/alertmanager", withCORS(alertHandler.AlertmanagerWebhook))
+	http.HandleFunc("/api/alerts", withCORS(alertHandler.ListAlerts))
+
+	// LLM usage and cost routes
+	http.HandleFunc("/api/usage", withCORS(usageHandler.Usage))
+	if analysisCache != nil {
+		http.HandleFunc("/api/cache", withCORS(cacheHandler.Stats))
+	}
 
 	// AI Connection routes (new)
 	http.HandleFunc("/api/connections", withCORS(connectionHandler.ListConnections))
@@ -70,10 +340,64 @@ func main() {
 
 	log.Printf("TraceMind AI Adapter starting on :%s (using model: %s)", port, modelName)
 	log.Printf("Available endpoints:")
-	log.Printf("  - Trace Analysis: /api/analyze, /api/evaluate")
+	log.Printf("  - Trace Analysis: /api/analyze, /api/evaluate, /api/evaluations")
+	if pipeline.Similar != nil {
+		log.Printf("  - Similar Incidents: /api/similar")
+	}
+	log.Printf("  - Runbooks: /api/runbooks")
+	log.Printf("  - Prompt Templates: /api/prompts, /api/prompts/{name}, /api/prompts/{name}/activate")
+	log.Printf("  - Experiments: /api/experiments, /api/experiments/{id}, /api/experiments/{id}/report, /api/experiments/{id}/cancel, /api/datasets")
+	log.Printf("  - Sessions: /api/sessions/{id}, /api/sessions/{id}/messages")
+	log.Printf("  - Incidents: /api/incidents, /api/incidents/get, /api/incidents/resolve")
+	log.Printf("  - Metrics: /api/metrics/otlp, /api/metrics")
+	log.Printf("  - Changes: /api/changes")
+	log.Printf("  - Alertmanager: /api/alerts/alertmanager, /api/alerts")
+	log.Printf("  - LLM Usage: /api/usage")
+	if analysisCache != nil {
+		log.Printf("  - Analysis Cache: /api/cache")
+	}
 	log.Printf("  - AI Connections: /api/connections, /api/connections/create, /api/connections/test, /api/connections/delete")
 	log.Printf("  - Design Generation: /api/design/generate, /api/design/generate-stream")
 	if err := http.ListenAndServe(":"+port, nil); err != nil {
 		log.Fatal(err)
 	}
 }
+
+func envFloat(key string, fallback float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		log.Printf("Ignoring invalid %s=%q: %v", key, v, err)
+		return fallback
+	}
+	return f
+}
+
+func envInt(key string, fallback int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("Ignoring invalid %s=%q: %v", key, v, err)
+		return fallback
+	}
+	return n
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("Ignoring invalid %s=%q: %v", key, v, err)
+		return fallback
+	}
+	return d
+}